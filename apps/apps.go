@@ -1,14 +1,8 @@
 package apps
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"path/filepath"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/sisatech/api"
@@ -22,65 +16,48 @@ type appsTuple struct {
 	Name string `json:"name"`
 	Path string `json:"path"`
 	Type string `json:"type"`
+	Size int64  `json:"size"`
 }
 
 type appsListResponse []appsTuple
 
 // Exists checks if the named app is accessible to the client for the named
-// organization.
+// organization. If the path names some other kind of object, Exists returns an
+// *ErrNotAnApp.
 func Exists(client *api.Client, org, app string) (bool, error) {
 
-	dir, base := filepath.Split(app)
-	if dir == "." {
-		dir = ""
-	}
-	dir = strings.TrimSuffix(dir, "/")
-
-	url := client.URL("images/api/v3/orgs/%s/objects/?op=list&dir=%s", org, dir)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return false, err
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, err
-	}
-	if resp.Body != nil {
-		defer resp.Body.Close()
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return false, errors.New(resp.Status)
-	}
-
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return false, err
-	}
-
-	pl := make(appsListResponse, 0)
-	err = json.Unmarshal(data, &pl)
-	if err != nil {
-		return false, err
-	}
+	dir, base := splitPath(app)
 
-	for _, tuple := range pl {
-		if tuple.Name == base {
-			if tuple.Type == "app" {
-				return true, nil
+	found := false
+	err := listObjects(client, org, dir, func(tuple *appsTuple) error {
+		if tuple.Name != base {
+			return nil
+		}
+		if tuple.Type != "app" {
+			return &ErrNotAnApp{
+				Path:       app,
+				ActualType: tuple.Type,
 			}
-			return false, fmt.Errorf("object '%s' is type '%s'", app, tuple.Type)
 		}
+		found = true
+		return errStopPaging
+	})
+	if err != nil {
+		return false, err
 	}
 
-	return false, nil
+	return found, nil
 }
 
 type versionTuple struct {
 	Tag     string    `json:"tag"`
 	Version string    `json:"version"`
 	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+
+	Deprecated         bool   `json:"deprecated"`
+	DeprecationMessage string `json:"deprecation_message"`
 }
 
 type versionListResponse []versionTuple
@@ -100,59 +77,46 @@ func (v versionListResponse) Less(i, j int) bool {
 }
 
 // ResolveVersionToID attempts to resolve the provided version for the named
-// app, converting it to a version ID.
+// app, converting it to a version ID. An empty version resolves to the newest
+// version that has not been deprecated, unless every version has been.
 func ResolveVersionToID(client *api.Client, org, app, version string) (string, error) {
 
-	dir, base := filepath.Split(app)
-	if dir == "." {
-		dir = ""
-	}
-	dir = strings.TrimSuffix(dir, "/")
-
-	url := client.URL("images/api/v3/orgs/%s/objects/%s?op=list&dir=%s", org, base, dir)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	v, err := listVersions(client, org, app)
 	if err != nil {
 		return "", err
 	}
 
-	resp, err := client.Do(req)
+	tuple, err := v.resolve(version)
 	if err != nil {
 		return "", err
 	}
-	if resp.Body != nil {
-		defer resp.Body.Close()
-	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", errors.New(resp.Status)
-	}
-
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
+	return tuple.Version, nil
+}
 
-	v := make(versionListResponse, 0)
-	err = json.Unmarshal(data, &v)
-	if err != nil {
-		return "", err
-	}
+// resolve finds the version in the listing with the given ID or tag, or the
+// latest version if version is empty.
+func (v versionListResponse) resolve(version string) (*versionTuple, error) {
 
 	if len(v) == 0 {
-		return "", ErrVersionNotExists
+		return nil, ErrVersionNotExists
 	}
 
 	if version == "" {
 		sort.Sort(v)
-		return v[len(v)-1].Version, nil
+		for i := len(v) - 1; i >= 0; i-- {
+			if !v[i].Deprecated {
+				return &v[i], nil
+			}
+		}
+		return &v[len(v)-1], nil
 	}
 
-	for _, tuple := range v {
-		if tuple.Version == version || tuple.Tag == version {
-			return tuple.Version, nil
+	for i := range v {
+		if v[i].Version == version || v[i].Tag == version {
+			return &v[i], nil
 		}
 	}
 
-	return "", ErrVersionNotExists
-
+	return nil, ErrVersionNotExists
 }
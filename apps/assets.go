@@ -0,0 +1,73 @@
+package apps
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// SetIcon replaces the icon shown for the named app in the VMS catalog with
+// the image read from r. The filename is passed to VMS so that it can
+// determine the image format, and should have an extension such as ".png".
+func SetIcon(client *api.Client, org, app, filename string, r io.Reader) error {
+	return uploadAsset(client, org, app, "icon", filename, r)
+}
+
+// SetReadme replaces the README shown for the named app in the VMS catalog
+// with the Markdown document read from r.
+func SetReadme(client *api.Client, org, app string, r io.Reader) error {
+	return uploadAsset(client, org, app, "readme", "README.md", r)
+}
+
+// uploadAsset uploads a file to one of the named app's multipart asset
+// endpoints.
+func uploadAsset(client *api.Client, org, app, op, filename string, r io.Reader) error {
+
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, r)
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	dir, base := splitPath(app)
+
+	query := url.Values{}
+	query.Set("op", op)
+	query.Set("dir", dir)
+
+	req, err := http.NewRequest(http.MethodPut, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return responseError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package apps
+
+import (
+	"github.com/sisatech/api"
+)
+
+// ExistsBatch is like Exists, but checks many apps at once, listing each
+// directory involved only once. It returns a map from each of the given paths
+// to whether it names an accessible app. Unlike Exists, a path that names an
+// object of some other type is simply reported as false.
+func ExistsBatch(client *api.Client, org string, paths []string) (map[string]bool, error) {
+
+	dirs := make(map[string]map[string][]string)
+	results := make(map[string]bool)
+
+	for _, p := range paths {
+		results[p] = false
+		dir, base := splitPath(p)
+		if dirs[dir] == nil {
+			dirs[dir] = make(map[string][]string)
+		}
+		dirs[dir][base] = append(dirs[dir][base], p)
+	}
+
+	for dir, names := range dirs {
+		err := listObjects(client, org, dir, func(tuple *appsTuple) error {
+			if tuple.Type != "app" {
+				return nil
+			}
+			for _, p := range names[tuple.Name] {
+				results[p] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,110 @@
+package apps
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// ConfigChange describes a single difference between the configuration of two
+// versions of an app. From is empty for keys added in the newer version, and
+// To is empty for keys it removed.
+type ConfigChange struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// VersionComparison describes what changed between two versions of an app.
+// SizeDelta is the change in package size from From to To. ConfigChanges and
+// Notes, the release notes recorded against the versions in between, are only
+// filled in if VMS supports comparing versions.
+type VersionComparison struct {
+	From          *Version
+	To            *Version
+	SizeDelta     int64
+	ConfigChanges []ConfigChange
+	Notes         string
+}
+
+type compareResponse struct {
+	Config []ConfigChange `json:"config"`
+	Notes  string         `json:"notes"`
+}
+
+// CompareVersions describes the changes between two versions of the named app.
+// Either version may be a version ID or tag, or empty for the latest version.
+func CompareVersions(client *api.Client, org, app, from, to string) (*VersionComparison, error) {
+
+	v, err := listVersions(client, org, app)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := v.resolve(from)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := v.resolve(to)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &VersionComparison{
+		From:          a.export(),
+		To:            b.export(),
+		SizeDelta:     b.Size - a.Size,
+		ConfigChanges: make([]ConfigChange, 0),
+	}
+
+	dir, base := splitPath(app)
+
+	query := url.Values{}
+	query.Set("op", "compare")
+	query.Set("dir", dir)
+	query.Set("from", a.Version)
+	query.Set("to", b.Version)
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return c, nil
+	default:
+		return nil, responseError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	pl := new(compareResponse)
+	err = json.Unmarshal(data, pl)
+	if err != nil {
+		return nil, err
+	}
+
+	if pl.Config != nil {
+		c.ConfigChanges = pl.Config
+	}
+	c.Notes = pl.Notes
+
+	return c, nil
+}
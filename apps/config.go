@@ -0,0 +1,123 @@
+package apps
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// ConfigPort is a port an app's configuration exposes.
+type ConfigPort struct {
+	Port     int
+	Protocol string
+}
+
+// Config is the Vorteil configuration packaged with a version of an app. Args
+// and Env are the command line arguments and default environment of the app's
+// first program, and Ports lists the ports exposed by its network
+// interfaces, sorted by protocol and then port. Raw contains the complete
+// configuration document as returned by VMS.
+type Config struct {
+	Args  []string
+	Env   map[string]string
+	Ports []ConfigPort
+	Raw   json.RawMessage
+}
+
+type configPL struct {
+	Programs []struct {
+		Args string   `json:"args"`
+		Env  []string `json:"env"`
+	} `json:"programs"`
+	Networks []struct {
+		HTTP  []string `json:"http"`
+		HTTPS []string `json:"https"`
+		TCP   []string `json:"tcp"`
+		UDP   []string `json:"udp"`
+	} `json:"networks"`
+}
+
+// GetConfig returns the configuration packaged with a version of the named
+// app. The version may be a version ID or tag, or empty for the latest
+// version.
+func GetConfig(client *api.Client, org, app, version string) (*Config, error) {
+
+	id, err := ResolveVersionToID(client, org, app, version)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, base := splitPath(app)
+
+	query := url.Values{}
+	query.Set("op", "config")
+	query.Set("dir", dir)
+	query.Set("version", id)
+
+	data, err := getJSON(client, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()))
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	pl := new(configPL)
+	err = json.Unmarshal(data, pl)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
+		Args:  make([]string, 0),
+		Env:   make(map[string]string),
+		Ports: make([]ConfigPort, 0),
+		Raw:   json.RawMessage(data),
+	}
+
+	if len(pl.Programs) > 0 {
+		cfg.Args = strings.Fields(pl.Programs[0].Args)
+		for _, kv := range pl.Programs[0].Env {
+			i := strings.Index(kv, "=")
+			if i < 0 {
+				cfg.Env[kv] = ""
+				continue
+			}
+			cfg.Env[kv[:i]] = kv[i+1:]
+		}
+	}
+
+	for _, n := range pl.Networks {
+		for protocol, ports := range map[string][]string{
+			"http":  n.HTTP,
+			"https": n.HTTPS,
+			"tcp":   n.TCP,
+			"udp":   n.UDP,
+		} {
+			for _, s := range ports {
+				// Ports may be given as "port" or "port:external".
+				if i := strings.Index(s, ":"); i >= 0 {
+					s = s[:i]
+				}
+				port, err := strconv.Atoi(strings.TrimSpace(s))
+				if err != nil {
+					continue
+				}
+				cfg.Ports = append(cfg.Ports, ConfigPort{
+					Port:     port,
+					Protocol: protocol,
+				})
+			}
+		}
+	}
+
+	sort.Slice(cfg.Ports, func(i, j int) bool {
+		if cfg.Ports[i].Protocol != cfg.Ports[j].Protocol {
+			return cfg.Ports[i].Protocol < cfg.Ports[j].Protocol
+		}
+		return cfg.Ports[i].Port < cfg.Ports[j].Port
+	})
+
+	return cfg, nil
+}
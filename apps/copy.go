@@ -0,0 +1,151 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/sisatech/api"
+)
+
+type copyPL struct {
+	Org      string   `json:"org"`
+	Dir      string   `json:"dir"`
+	Name     string   `json:"name"`
+	Versions []string `json:"versions,omitempty"`
+}
+
+// errCopyUnsupported is returned by serverCopy if VMS does not support copying
+// apps server-side.
+var errCopyUnsupported = errors.New("server-side copy not supported")
+
+// Copy copies versions of an app from one organization's repository to
+// another's, or to a different path within the same repository, creating the
+// destination app if necessary. Versions may be version IDs or tags; if none
+// are given every version is copied. Tags are copied along with the versions
+// they apply to.
+//
+// Copy asks VMS to perform the copy itself where that is supported, and
+// otherwise downloads each version and uploads it again.
+func Copy(client *api.Client, srcOrg, srcApp, dstOrg, dstApp string, versions ...string) error {
+
+	v, err := listVersions(client, srcOrg, srcApp)
+	if err != nil {
+		return err
+	}
+
+	var list []*versionTuple
+	if len(versions) == 0 {
+		for i := range v {
+			list = append(list, &v[i])
+		}
+	} else {
+		for _, version := range versions {
+			tuple, err := v.resolve(version)
+			if err != nil {
+				return err
+			}
+			list = append(list, tuple)
+		}
+	}
+
+	ids := make([]string, len(list))
+	for i, tuple := range list {
+		ids[i] = tuple.Version
+	}
+
+	err = serverCopy(client, srcOrg, srcApp, dstOrg, dstApp, ids)
+	if err != errCopyUnsupported {
+		return err
+	}
+
+	api.Log.Debug("Server-side copy unsupported, copying via download", "app", srcApp)
+
+	// Copy oldest first, so the destination versions are created in the same
+	// order as the originals.
+	sorted := make(versionListResponse, len(list))
+	for i, tuple := range list {
+		sorted[i] = *tuple
+	}
+	sort.Stable(sorted)
+
+	for _, tuple := range sorted {
+		err = copyVersion(client, srcOrg, srcApp, dstOrg, dstApp, &tuple)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serverCopy asks VMS to copy versions of an app, returning errCopyUnsupported
+// if it can't.
+func serverCopy(client *api.Client, srcOrg, srcApp, dstOrg, dstApp string, versions []string) error {
+
+	dir, base := splitPath(srcApp)
+	toDir, toBase := splitPath(dstApp)
+	if base == "" || toBase == "" {
+		return errors.New("app paths must not be empty")
+	}
+
+	pl, err := json.Marshal(&copyPL{
+		Org:      dstOrg,
+		Dir:      toDir,
+		Name:     toBase,
+		Versions: versions,
+	})
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("op", "copy")
+	query.Set("dir", dir)
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("images/api/v3/orgs/%s/objects/%s?%s", srcOrg, base, query.Encode()), bytes.NewReader(pl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return errCopyUnsupported
+	}
+
+	return responseError(resp)
+}
+
+// copyVersion copies a single version by downloading and re-uploading it.
+func copyVersion(client *api.Client, srcOrg, srcApp, dstOrg, dstApp string, tuple *versionTuple) error {
+
+	r, info, err := Download(client, srcOrg, srcApp, tuple.Version)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	opts := &UploadOptions{
+		ContentType: info.ContentType,
+	}
+	if tuple.Tag != "" {
+		opts.Tags = []string{tuple.Tag}
+	}
+
+	_, err = Upload(client, dstOrg, dstApp, r, opts)
+	return err
+}
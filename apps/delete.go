@@ -0,0 +1,53 @@
+package apps
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// ErrAppHasVersions is returned whenever an app cannot be deleted because it
+// still has versions, and deletion was not forced.
+var ErrAppHasVersions = errors.New("app has versions")
+
+// Delete deletes the named app from the organization's repository. VMS refuses
+// to delete an app that still has versions unless force is true, in which case
+// every version is deleted along with it.
+func Delete(client *api.Client, org, app string, force bool) error {
+
+	dir, base := splitPath(app)
+	if base == "" {
+		return errors.New("app path must not be empty")
+	}
+
+	query := url.Values{}
+	query.Set("dir", dir)
+	if force {
+		query.Set("force", "true")
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		return ErrAppHasVersions
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return responseError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// ErrVersionDeprecated is returned whenever a deprecated version is resolved
+// under a ResolutionPolicy that rejects deprecated versions. Message is the
+// message given when the version was deprecated.
+type ErrVersionDeprecated struct {
+	Version string
+	Message string
+}
+
+func (e *ErrVersionDeprecated) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("version '%s' is deprecated", e.Version)
+	}
+	return fmt.Sprintf("version '%s' is deprecated: %s", e.Version, e.Message)
+}
+
+type deprecatePL struct {
+	Message string `json:"message"`
+}
+
+// Deprecate marks a version of the named app as deprecated, recording a
+// message explaining why. Deprecated versions remain available, but are
+// skipped when resolving the latest version, and can be rejected with a
+// ResolutionPolicy. The version may be a version ID or tag.
+func Deprecate(client *api.Client, org, app, version, message string) error {
+	return setDeprecation(client, org, app, version, http.MethodPut, &deprecatePL{Message: message})
+}
+
+// Undeprecate removes the deprecation from a version of the named app.
+func Undeprecate(client *api.Client, org, app, version string) error {
+	return setDeprecation(client, org, app, version, http.MethodDelete, nil)
+}
+
+func setDeprecation(client *api.Client, org, app, version, method string, pl *deprecatePL) error {
+
+	id, err := ResolveVersionToID(client, org, app, version)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if pl != nil {
+		body, err = json.Marshal(pl)
+		if err != nil {
+			return err
+		}
+	}
+
+	dir, base := splitPath(app)
+
+	query := url.Values{}
+	query.Set("op", "deprecate")
+	query.Set("dir", dir)
+	query.Set("version", id)
+
+	req, err := http.NewRequest(method, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if pl != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return responseError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package apps
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// ErrDirNotEmpty is returned whenever a directory cannot be deleted because it
+// still contains objects, and deletion was not forced.
+var ErrDirNotEmpty = errors.New("directory not empty")
+
+// CreateDir creates the named directory within the organization's repository,
+// along with any missing parent directories. It is not an error for the
+// directory to exist already.
+func CreateDir(client *api.Client, org, dir string) error {
+
+	dir = cleanDir(dir)
+	if dir == "" {
+		return nil
+	}
+
+	elems := strings.Split(dir, "/")
+	for i := range elems {
+		err := mkdir(client, org, strings.Join(elems[:i], "/"), elems[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mkdir creates a single directory named base within the parent directory,
+// which must already exist.
+func mkdir(client *api.Client, org, parent, base string) error {
+
+	query := url.Values{}
+	query.Set("op", "mkdir")
+	query.Set("dir", parent)
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		return nil
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return api.NewAPIError(resp)
+	}
+
+	return nil
+}
+
+// DeleteDir deletes the named directory from the organization's repository.
+// VMS refuses to delete a directory that is not empty unless force is true, in
+// which case everything within it, including apps and their versions, is
+// deleted along with it.
+func DeleteDir(client *api.Client, org, dir string, force bool) error {
+
+	parent, base := splitPath(dir)
+	if base == "" {
+		return errors.New("cannot delete the root directory")
+	}
+
+	query := url.Values{}
+	query.Set("op", "rmdir")
+	query.Set("dir", parent)
+	if force {
+		query.Set("force", "true")
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		return ErrDirNotEmpty
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return api.NewAPIError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package apps
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// PackageInfo describes a package returned by Download. Size is -1 if VMS did
+// not report it. Checksum is the hex-encoded SHA-256 checksum of the package
+// reported by VMS, either with the download or in the app's version listing,
+// and is empty if VMS did not report one.
+type PackageInfo struct {
+	Version     string
+	Size        int64
+	Checksum    string
+	ContentType string
+}
+
+// Download fetches the package for a version of the named app from the
+// organization's repository. The version may be a version ID or tag, or empty
+// for the latest version, and is resolved with ResolveVersionToID. The caller
+// must close the returned io.ReadCloser.
+//
+// If VMS reports a checksum for the package, the data is verified as it is
+// read, and reading the end of it returns ErrChecksumMismatch rather than
+// io.EOF if it does not match.
+func Download(client *api.Client, org, app, version string) (io.ReadCloser, *PackageInfo, error) {
+
+	_, base := splitPath(app)
+	if base == "" {
+		return nil, nil, errors.New("app path must not be empty")
+	}
+
+	v, err := listVersions(client, org, app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tuple, err := v.resolve(version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return download(client, org, app, tuple, "")
+}
+
+// download fetches the package for a resolved version. If checksum is not
+// empty the package is verified against it alone, and a different checksum
+// reported by VMS with the download is an error. Otherwise the package is
+// verified against whichever checksum VMS reports.
+func download(client *api.Client, org, app string, tuple *versionTuple, checksum string) (io.ReadCloser, *PackageInfo, error) {
+
+	dir, base := splitPath(app)
+	id := tuple.Version
+
+	query := url.Values{}
+	query.Set("op", "download")
+	query.Set("dir", dir)
+	query.Set("version", id)
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, nil, responseError(resp)
+	}
+
+	info := &PackageInfo{
+		Version:     id,
+		Size:        resp.ContentLength,
+		Checksum:    resp.Header.Get("X-Checksum-Sha256"),
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	if checksum != "" {
+		if info.Checksum != "" && !strings.EqualFold(info.Checksum, checksum) {
+			resp.Body.Close()
+			return nil, nil, ErrChecksumMismatch
+		}
+		info.Checksum = checksum
+	}
+
+	if info.Checksum == "" {
+		info.Checksum = tuple.SHA256
+	}
+
+	if info.Checksum == "" {
+		return resp.Body, info, nil
+	}
+
+	return newVerifyingReader(resp.Body, info.Checksum), info, nil
+}
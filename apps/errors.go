@@ -0,0 +1,57 @@
+package apps
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// ErrAppNotFound is returned whenever VMS reports that an app does not exist.
+// The underlying *api.APIError can be retrieved with errors.As.
+var ErrAppNotFound = errors.New("app not found")
+
+// ErrNotAnApp is returned whenever a path that was expected to name an app
+// names some other kind of object, such as a directory.
+type ErrNotAnApp struct {
+	Path       string
+	ActualType string
+}
+
+func (e *ErrNotAnApp) Error() string {
+	return fmt.Sprintf("object '%s' is type '%s'", e.Path, e.ActualType)
+}
+
+// notFoundError associates an *api.APIError with ErrAppNotFound.
+type notFoundError struct {
+	err *api.APIError
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrAppNotFound, e.err)
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrAppNotFound
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+// responseError converts an unexpected response to a request about an app into
+// an error.
+func responseError(resp *http.Response) error {
+	return appError(api.NewAPIError(resp))
+}
+
+// appError converts an error from a request about an app so that it matches
+// ErrAppNotFound if VMS reported the app missing.
+func appError(err error) error {
+	var e *api.APIError
+	if errors.As(err, &e) && e.StatusCode == http.StatusNotFound {
+		return &notFoundError{err: e}
+	}
+	return err
+}
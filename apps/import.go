@@ -0,0 +1,90 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// ImportOptions controls how ImportFromURL publishes a package. Tags are
+// applied to the new version once it has been created. SHA256, if not empty,
+// is the hex-encoded checksum VMS should verify the fetched package against.
+type ImportOptions struct {
+	Tags   []string
+	SHA256 string
+}
+
+type importPL struct {
+	URL    string   `json:"url"`
+	Tags   []string `json:"tags,omitempty"`
+	SHA256 string   `json:"sha256,omitempty"`
+}
+
+// ImportFromURL asks VMS to fetch a package from an external URL and publish
+// it as a new version of the app at the given path within the organization's
+// repository, creating the app if necessary. The package never passes through
+// the client. It returns the ID of the new version. opts may be nil.
+func ImportFromURL(client *api.Client, org, app, src string, opts *ImportOptions) (string, error) {
+
+	if opts == nil {
+		opts = new(ImportOptions)
+	}
+
+	dir, base := splitPath(app)
+	if base == "" {
+		return "", errors.New("app path must not be empty")
+	}
+
+	pl, err := json.Marshal(&importPL{
+		URL:    src,
+		Tags:   opts.Tags,
+		SHA256: opts.SHA256,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("op", "import")
+	query.Set("dir", dir)
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), bytes.NewReader(pl))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", responseError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	ur := new(uploadChunkResponse)
+	err = json.Unmarshal(data, ur)
+	if err != nil {
+		return "", err
+	}
+
+	if ur.Version == "" {
+		return "", errors.New("import response did not include a version")
+	}
+
+	return ur.Version, nil
+}
@@ -0,0 +1,128 @@
+package apps
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// SkipDir can be returned by a WalkFunc to prevent Walk from descending into
+// the directory it was called for.
+var SkipDir = errors.New("skip this directory")
+
+// EntryType identifies the kind of object an Entry describes.
+type EntryType string
+
+// Types of object found in an organization's repository.
+const (
+	TypeApp EntryType = "app"
+	TypeDir EntryType = "dir"
+)
+
+// Entry describes an object within an organization's repository. Path is the
+// full slash-separated path to the object from the root of the repository.
+// Size is the total size in bytes of every version of an app, as reported by
+// VMS, and is zero for directories.
+type Entry struct {
+	Name string
+	Path string
+	Type EntryType
+	Size int64
+}
+
+// IsApp reports whether the entry is an app.
+func (e *Entry) IsApp() bool {
+	return e.Type == TypeApp
+}
+
+// IsDir reports whether the entry is a directory.
+func (e *Entry) IsDir() bool {
+	return e.Type == TypeDir
+}
+
+// cleanDir normalizes a repository directory path, so that the root of the
+// repository is the empty string.
+func cleanDir(dir string) string {
+	dir = strings.Trim(dir, "/")
+	if dir == "." {
+		dir = ""
+	}
+	return dir
+}
+
+// splitPath separates the path to an object in the repository into its parent
+// directory, normalized by cleanDir, and its name.
+func splitPath(p string) (string, string) {
+	dir, base := filepath.Split(strings.Trim(p, "/"))
+	return cleanDir(dir), base
+}
+
+// List returns an alphabetized list of the objects within the named directory
+// of the organization's repository. An empty dir lists the root.
+func List(client *api.Client, org, dir string) ([]*Entry, error) {
+
+	dir = cleanDir(dir)
+
+	list := make([]*Entry, 0)
+	err := listObjects(client, org, dir, func(tuple *appsTuple) error {
+		p := tuple.Path
+		if p == "" {
+			p = path.Join(dir, tuple.Name)
+		}
+		list = append(list, &Entry{
+			Name: tuple.Name,
+			Path: strings.Trim(p, "/"),
+			Type: EntryType(tuple.Type),
+			Size: tuple.Size,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list, nil
+}
+
+// WalkFunc is called by Walk for every object it visits. If it returns SkipDir
+// for a directory, Walk does not descend into it. Any other non-nil error
+// stops the walk and is returned by Walk.
+type WalkFunc func(entry *Entry) error
+
+// Walk visits every object within the named directory of the organization's
+// repository, and within its subdirectories, in alphabetical depth-first
+// order, calling fn for each. Every directory is visited before its contents.
+func Walk(client *api.Client, org, dir string, fn WalkFunc) error {
+
+	list, err := List(client, org, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range list {
+		err = fn(entry)
+		if err == SkipDir {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			err = Walk(client, org, entry.Path, fn)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
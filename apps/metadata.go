@@ -0,0 +1,95 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// Metadata is the human-friendly information recorded against an app. Summary
+// is a one line description, and Description may be longer. Annotations are
+// arbitrary key-value pairs.
+type Metadata struct {
+	Summary     string            `json:"summary"`
+	Description string            `json:"description"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+func metadataURL(client *api.Client, org, app string) string {
+	dir, base := splitPath(app)
+	query := url.Values{}
+	query.Set("op", "metadata")
+	query.Set("dir", dir)
+	return client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode())
+}
+
+// GetMetadata returns the metadata of the named app.
+func GetMetadata(client *api.Client, org, app string) (*Metadata, error) {
+
+	req, err := http.NewRequest(http.MethodGet, metadataURL(client, org, app), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	md := new(Metadata)
+	err = json.Unmarshal(data, md)
+	if err != nil {
+		return nil, err
+	}
+
+	if md.Annotations == nil {
+		md.Annotations = make(map[string]string)
+	}
+
+	return md, nil
+}
+
+// SetMetadata replaces the metadata of the named app.
+func SetMetadata(client *api.Client, org, app string, md *Metadata) error {
+
+	pl, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, metadataURL(client, org, app), bytes.NewReader(pl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
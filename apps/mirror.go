@@ -0,0 +1,205 @@
+package apps
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// MirrorAnnotationPrefix prefixes the annotations Mirror records against each
+// mirrored app. Each annotation maps the ID of a version in the source
+// repository to the ID of its copy in the destination repository, and is how
+// later mirrors know which versions have already been copied.
+const MirrorAnnotationPrefix = "mirror.source-version."
+
+// MirrorFilter decides which apps Mirror replicates. It is called with every
+// app in the source repository, and should return true for those that should
+// be mirrored.
+type MirrorFilter func(entry *Entry) bool
+
+// MirrorReport summarizes what Mirror did. Versions maps each mirrored app to
+// the IDs, in the source repository, of the versions copied to the destination
+// during this run. Tags counts the tags applied or moved in the destination.
+// Failed maps the apps that could not be mirrored to the reason why.
+type MirrorReport struct {
+	Apps     []string
+	Versions map[string][]string
+	Tags     int
+	Failed   map[string]error
+}
+
+// Mirror replicates apps, their versions, and their tags from one
+// organization's repository to another's, which may be on a different VMS
+// installation. Apps keep the same paths in the destination. A nil filter
+// mirrors every app.
+//
+// Mirroring is incremental: versions are matched by their source version ID,
+// so only versions added since the last mirror are downloaded and uploaded
+// again. Tags are always brought up to date. Versions deleted from the source
+// are left alone in the destination.
+//
+// Failures to mirror individual apps are recorded in the report rather than
+// stopping the mirror; Mirror only returns an error if it could not walk the
+// source repository.
+func Mirror(srcClient *api.Client, srcOrg string, dstClient *api.Client, dstOrg string, filter MirrorFilter) (*MirrorReport, error) {
+
+	report := &MirrorReport{
+		Apps:     make([]string, 0),
+		Versions: make(map[string][]string),
+		Failed:   make(map[string]error),
+	}
+
+	err := Walk(srcClient, srcOrg, "", func(entry *Entry) error {
+		if !entry.IsApp() {
+			return nil
+		}
+		if filter != nil && !filter(entry) {
+			return nil
+		}
+
+		copied, tags, err := mirrorApp(srcClient, srcOrg, dstClient, dstOrg, entry.Path)
+		if err != nil {
+			report.Failed[entry.Path] = err
+		}
+		if err == nil || len(copied) > 0 {
+			report.Apps = append(report.Apps, entry.Path)
+			report.Versions[entry.Path] = copied
+		}
+		report.Tags += tags
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	sort.Strings(report.Apps)
+
+	return report, nil
+}
+
+// mirrorApp brings a single app in the destination up to date with the
+// source, returning the source IDs of the versions it copied and the number
+// of tags it changed.
+func mirrorApp(srcClient *api.Client, srcOrg string, dstClient *api.Client, dstOrg, app string) ([]string, int, error) {
+
+	v, err := listVersions(srcClient, srcOrg, app)
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Stable(v)
+
+	md, err := GetMetadata(dstClient, dstOrg, app)
+	if errors.Is(err, ErrAppNotFound) {
+		md, err = new(Metadata), nil
+		dir, _ := splitPath(app)
+		err = CreateDir(dstClient, dstOrg, dir)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if md.Annotations == nil {
+		md.Annotations = make(map[string]string)
+	}
+
+	copied := make([]string, 0)
+
+	// Record whatever was copied even if a later version fails, so that the
+	// next mirror doesn't copy it again.
+	var copyErr error
+	for i := range v {
+		tuple := &v[i]
+		key := MirrorAnnotationPrefix + tuple.Version
+		if _, ok := md.Annotations[key]; ok {
+			continue
+		}
+
+		id, err := mirrorVersion(srcClient, srcOrg, dstClient, dstOrg, app, tuple)
+		if err != nil {
+			copyErr = err
+			break
+		}
+
+		md.Annotations[key] = id
+		copied = append(copied, tuple.Version)
+	}
+
+	if len(copied) > 0 {
+		src, err := GetMetadata(srcClient, srcOrg, app)
+		if err != nil {
+			return copied, 0, err
+		}
+		md.Summary = src.Summary
+		md.Description = src.Description
+		for k, val := range src.Annotations {
+			if !strings.HasPrefix(k, MirrorAnnotationPrefix) {
+				md.Annotations[k] = val
+			}
+		}
+
+		err = SetMetadata(dstClient, dstOrg, app, md)
+		if err != nil {
+			return copied, 0, err
+		}
+	}
+
+	if copyErr != nil {
+		return copied, 0, copyErr
+	}
+
+	tags, err := mirrorTags(dstClient, dstOrg, app, v, md.Annotations)
+	return copied, tags, err
+}
+
+// mirrorVersion copies a single version to the destination, returning the ID
+// of the new version.
+func mirrorVersion(srcClient *api.Client, srcOrg string, dstClient *api.Client, dstOrg, app string, tuple *versionTuple) (string, error) {
+
+	api.Log.Debug("Mirroring version", "app", app, "version", tuple.Version)
+
+	r, info, err := Download(srcClient, srcOrg, app, tuple.Version)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	return Upload(dstClient, dstOrg, app, r, &UploadOptions{
+		ContentType: info.ContentType,
+	})
+}
+
+// mirrorTags applies each of the source app's tags to the destination copy of
+// the version it is applied to, returning the number of tags it changed.
+func mirrorTags(dstClient *api.Client, dstOrg, app string, src versionListResponse, ids map[string]string) (int, error) {
+
+	want := make(map[string]string)
+	for _, tuple := range src {
+		if id, ok := ids[MirrorAnnotationPrefix+tuple.Version]; ok && tuple.Tag != "" {
+			want[tuple.Tag] = id
+		}
+	}
+	if len(want) == 0 {
+		return 0, nil
+	}
+
+	current, err := ListTags(dstClient, dstOrg, app)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for tag, id := range want {
+		if current[tag] == id {
+			continue
+		}
+
+		err = SetTag(dstClient, dstOrg, app, tag, id)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, nil
+}
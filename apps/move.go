@@ -0,0 +1,68 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// ErrObjectExists is returned whenever an operation would replace an object
+// that already exists in the repository.
+var ErrObjectExists = errors.New("object already exists")
+
+type movePL struct {
+	Dir  string `json:"dir"`
+	Name string `json:"name"`
+}
+
+// Move moves or renames an app or directory within the organization's
+// repository. Apps keep all of their versions and tags. The destination's
+// parent directory must already exist, and the destination itself must not.
+func Move(client *api.Client, org, from, to string) error {
+
+	dir, base := splitPath(from)
+	toDir, toBase := splitPath(to)
+	if base == "" || toBase == "" {
+		return errors.New("paths must not be empty")
+	}
+
+	pl, err := json.Marshal(&movePL{
+		Dir:  toDir,
+		Name: toBase,
+	})
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("op", "move")
+	query.Set("dir", dir)
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), bytes.NewReader(pl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		return ErrObjectExists
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
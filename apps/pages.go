@@ -0,0 +1,97 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// errStopPaging can be returned by the function passed to listPages to stop
+// fetching pages early.
+var errStopPaging = errors.New("stop paging")
+
+// listPages fetches a listing one page of DefaultPageSize items at a time,
+// passing the raw items of each page to fn, until a short page is returned.
+// The url must already contain a query string.
+func listPages(client *api.Client, url string, fn func(items []json.RawMessage) error) error {
+
+	var first json.RawMessage
+
+	for page := 0; ; page++ {
+
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s&page=%d&per_page=%d", url, page, DefaultPageSize), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err = api.NewAPIError(resp)
+			resp.Body.Close()
+			return err
+		}
+
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		items := make([]json.RawMessage, 0)
+		err = json.Unmarshal(data, &items)
+		if err != nil {
+			return err
+		}
+
+		// A server that ignores the paging parameters returns the whole
+		// listing every time, so a repeated page means we are done.
+		if page > 0 && len(items) > 0 && bytes.Equal(items[0], first) {
+			return nil
+		}
+		if len(items) > 0 {
+			first = items[0]
+		}
+
+		err = fn(items)
+		if err == errStopPaging {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(items) < DefaultPageSize {
+			return nil
+		}
+	}
+}
+
+// listObjects returns every object within the named directory of the
+// organization's repository, fetching as many pages as necessary.
+func listObjects(client *api.Client, org, dir string, fn func(tuple *appsTuple) error) error {
+
+	url := client.URL("images/api/v3/orgs/%s/objects/?op=list&dir=%s", org, dir)
+	return listPages(client, url, func(items []json.RawMessage) error {
+		for _, item := range items {
+			tuple := new(appsTuple)
+			err := json.Unmarshal(item, tuple)
+			if err != nil {
+				return err
+			}
+			err = fn(tuple)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,197 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// Permission is something a user or team may be allowed to do with an app.
+type Permission string
+
+// Permissions that may be granted on an app.
+const (
+	PermissionView    Permission = "view"
+	PermissionDeploy  Permission = "deploy"
+	PermissionPublish Permission = "publish"
+)
+
+// SubjectType identifies the kind of subject a Grant applies to.
+type SubjectType string
+
+// Kinds of subject that may be granted permissions.
+const (
+	SubjectUser SubjectType = "user"
+	SubjectTeam SubjectType = "team"
+)
+
+// Grant gives a single user or team of the organization a set of permissions
+// on an app.
+type Grant struct {
+	Type        SubjectType  `json:"type"`
+	Subject     string       `json:"subject"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// Allows reports whether the grant includes the permission.
+func (g *Grant) Allows(permission Permission) bool {
+	for _, x := range g.Permissions {
+		if x == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionsURL(client *api.Client, org, app string) string {
+	dir, base := splitPath(app)
+	query := url.Values{}
+	query.Set("op", "permissions")
+	query.Set("dir", dir)
+	return client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode())
+}
+
+// GetPermissions returns every grant of permissions on the named app.
+func GetPermissions(client *api.Client, org, app string) ([]*Grant, error) {
+
+	req, err := http.NewRequest(http.MethodGet, permissionsURL(client, org, app), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Grant, 0)
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// SetPermissions replaces every grant of permissions on the named app.
+func SetPermissions(client *api.Client, org, app string, grants []*Grant) error {
+
+	if grants == nil {
+		grants = make([]*Grant, 0)
+	}
+
+	pl, err := json.Marshal(grants)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, permissionsURL(client, org, app), bytes.NewReader(pl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// GrantPermissions gives a user or team the listed permissions on the named
+// app, in addition to any it already has.
+func GrantPermissions(client *api.Client, org, app string, typ SubjectType, subject string, permissions ...Permission) error {
+
+	grants, err := GetPermissions(client, org, app)
+	if err != nil {
+		return err
+	}
+
+	var g *Grant
+	for _, x := range grants {
+		if x.Type == typ && x.Subject == subject {
+			g = x
+			break
+		}
+	}
+
+	if g == nil {
+		g = &Grant{Type: typ, Subject: subject}
+		grants = append(grants, g)
+	}
+
+	for _, p := range permissions {
+		if !g.Allows(p) {
+			g.Permissions = append(g.Permissions, p)
+		}
+	}
+
+	return SetPermissions(client, org, app, grants)
+}
+
+// RevokePermissions removes the listed permissions on the named app from a
+// user or team. If no permissions are listed, all of them are removed.
+func RevokePermissions(client *api.Client, org, app string, typ SubjectType, subject string, permissions ...Permission) error {
+
+	grants, err := GetPermissions(client, org, app)
+	if err != nil {
+		return err
+	}
+
+	list := make([]*Grant, 0, len(grants))
+	for _, g := range grants {
+		if g.Type != typ || g.Subject != subject {
+			list = append(list, g)
+			continue
+		}
+
+		if len(permissions) == 0 {
+			continue
+		}
+
+		kept := make([]Permission, 0)
+		for _, p := range g.Permissions {
+			revoke := false
+			for _, x := range permissions {
+				if p == x {
+					revoke = true
+					break
+				}
+			}
+			if !revoke {
+				kept = append(kept, p)
+			}
+		}
+
+		if len(kept) > 0 {
+			g.Permissions = kept
+			list = append(list, g)
+		}
+	}
+
+	return SetPermissions(client, org, app, list)
+}
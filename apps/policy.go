@@ -0,0 +1,96 @@
+package apps
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/sisatech/api"
+)
+
+// PrereleasePattern matches tags that conventionally mark prerelease builds,
+// such as "1.2.0-rc.1", "beta" or "nightly-2020-01-01".
+var PrereleasePattern = regexp.MustCompile(`(?i)(^|[-.+_])(alpha|beta|rc|pre|preview|dev|snapshot|nightly)([-.+_0-9]|$)`)
+
+// ResolutionPolicy restricts the versions ResolveWithPolicy will choose from
+// when asked for the latest version of an app. ExcludeUntagged skips versions
+// without a tag. Prerelease, if not nil, skips versions whose tag it matches.
+// Channel, if not empty, skips every version not tagged with it.
+//
+// RejectDeprecated skips deprecated versions when choosing the latest version,
+// and causes ResolveWithPolicy to fail with an *ErrVersionDeprecated if a
+// deprecated version is asked for explicitly.
+type ResolutionPolicy struct {
+	ExcludeUntagged  bool
+	Prerelease       *regexp.Regexp
+	Channel          string
+	RejectDeprecated bool
+}
+
+// StablePolicy is a ResolutionPolicy that picks the newest tagged version whose
+// tag does not mark it as a prerelease, and which has not been deprecated.
+var StablePolicy = &ResolutionPolicy{
+	ExcludeUntagged:  true,
+	Prerelease:       PrereleasePattern,
+	RejectDeprecated: true,
+}
+
+func (policy *ResolutionPolicy) allows(tuple *versionTuple) bool {
+
+	if policy.ExcludeUntagged && tuple.Tag == "" {
+		return false
+	}
+
+	if policy.Prerelease != nil && tuple.Tag != "" && policy.Prerelease.MatchString(tuple.Tag) {
+		return false
+	}
+
+	if policy.Channel != "" && tuple.Tag != policy.Channel {
+		return false
+	}
+
+	if policy.RejectDeprecated && tuple.Deprecated {
+		return false
+	}
+
+	return true
+}
+
+// ResolveWithPolicy is like ResolveVersionToID, but if version is empty it
+// returns the newest version allowed by the policy rather than simply the
+// newest version. Explicitly requested versions are only subject to the
+// policy's RejectDeprecated rule. A nil policy allows every version.
+func ResolveWithPolicy(client *api.Client, org, app, version string, policy *ResolutionPolicy) (string, error) {
+
+	if policy == nil {
+		return ResolveVersionToID(client, org, app, version)
+	}
+
+	v, err := listVersions(client, org, app)
+	if err != nil {
+		return "", err
+	}
+
+	if version != "" {
+		tuple, err := v.resolve(version)
+		if err != nil {
+			return "", err
+		}
+		if policy.RejectDeprecated && tuple.Deprecated {
+			return "", &ErrVersionDeprecated{
+				Version: tuple.Version,
+				Message: tuple.DeprecationMessage,
+			}
+		}
+		return tuple.Version, nil
+	}
+
+	sort.Stable(v)
+
+	for i := len(v) - 1; i >= 0; i-- {
+		if policy.allows(&v[i]) {
+			return v[i].Version, nil
+		}
+	}
+
+	return "", ErrVersionNotExists
+}
@@ -0,0 +1,102 @@
+package apps
+
+import (
+	"errors"
+	"path"
+
+	"github.com/sisatech/api"
+)
+
+// PromoteOptions controls how Promote moves a version between directories.
+// Tag, if not empty, is applied to the promoted version in the destination,
+// moving it from whichever version it was previously applied to there. If
+// Move is true the version is deleted from the source once it has been
+// promoted, rather than being left in place.
+type PromoteOptions struct {
+	Tag  string
+	Move bool
+}
+
+// Promote copies a version of an app from one directory of the organization's
+// repository to another, such as from "dev" to "staging", creating the app in
+// the destination directory if necessary. The app is named relative to both
+// directories, and the version may be a version ID or tag. It returns the ID
+// of the promoted version in the destination. opts may be nil.
+func Promote(client *api.Client, org, app, version, fromDir, toDir string, opts *PromoteOptions) (string, error) {
+
+	if opts == nil {
+		opts = new(PromoteOptions)
+	}
+
+	src := path.Join(cleanDir(fromDir), app)
+	dst := path.Join(cleanDir(toDir), app)
+	if src == dst {
+		return "", errors.New("cannot promote a version to the directory it is already in")
+	}
+
+	v, err := listVersions(client, org, src)
+	if err != nil {
+		return "", err
+	}
+
+	tuple, err := v.resolve(version)
+	if err != nil {
+		return "", err
+	}
+
+	before := make(map[string]bool)
+	existing, err := listVersions(client, org, dst)
+	if err != nil && !errors.Is(err, ErrAppNotFound) {
+		return "", err
+	}
+	for _, x := range existing {
+		before[x.Version] = true
+	}
+
+	err = Copy(client, org, src, org, dst, tuple.Version)
+	if err != nil {
+		return "", err
+	}
+
+	after, err := listVersions(client, org, dst)
+	if err != nil {
+		return "", err
+	}
+
+	// A server-side copy may keep the version's ID, whereas copying by
+	// uploading creates a new one.
+	id := ""
+	for _, x := range after {
+		if !before[x.Version] {
+			id = x.Version
+			break
+		}
+	}
+	if id == "" {
+		for _, x := range after {
+			if x.Version == tuple.Version {
+				id = x.Version
+				break
+			}
+		}
+	}
+	if id == "" {
+		return "", errors.New("promoted version not found in destination")
+	}
+
+	if opts.Tag != "" {
+		err = SetTag(client, org, dst, opts.Tag, id)
+		if err != nil {
+			return id, err
+		}
+	}
+
+	if opts.Move {
+		err = deleteVersion(client, org, src, tuple.Version)
+		if err != nil {
+			return id, err
+		}
+	}
+
+	return id, nil
+}
@@ -0,0 +1,139 @@
+package apps
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// DeleteVersion deletes a single version of the named app. The version may be
+// a version ID or tag.
+func DeleteVersion(client *api.Client, org, app, version string) error {
+
+	id, err := ResolveVersionToID(client, org, app, version)
+	if err != nil {
+		return err
+	}
+
+	return deleteVersion(client, org, app, id)
+}
+
+// deleteVersion deletes the version of the named app with the given ID.
+func deleteVersion(client *api.Client, org, app, id string) error {
+
+	dir, base := splitPath(app)
+
+	query := url.Values{}
+	query.Set("dir", dir)
+	query.Set("version", id)
+
+	req, err := http.NewRequest(http.MethodDelete, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// RetentionPolicy decides which versions of an app Prune keeps. A version is
+// kept if any of the rules keep it: KeepLast keeps the newest N versions,
+// KeepTagged keeps every tagged version, KeepNewerThan keeps versions created
+// within that long of now, and KeepInUse keeps versions that any of the
+// organization's deployments are running, as reported by Usages.
+//
+// If DryRun is true, Prune reports what it would delete without deleting
+// anything.
+type RetentionPolicy struct {
+	KeepLast      int
+	KeepTagged    bool
+	KeepNewerThan time.Duration
+	KeepInUse     bool
+	DryRun        bool
+}
+
+// PruneReport describes the versions Prune kept and deleted, oldest first. If
+// the policy was a dry run, Deleted lists the versions that would have been
+// deleted.
+type PruneReport struct {
+	Kept    []*Version
+	Deleted []*Version
+	DryRun  bool
+}
+
+// Prune deletes the versions of the named app that the policy does not keep.
+// A policy with no rules is an error, since it would delete every version. If
+// a deletion fails, Prune stops and returns the error along with a report of
+// the versions it had deleted so far.
+func Prune(client *api.Client, org, app string, policy *RetentionPolicy) (*PruneReport, error) {
+
+	if policy.KeepLast <= 0 && !policy.KeepTagged && policy.KeepNewerThan <= 0 && !policy.KeepInUse {
+		return nil, errors.New("retention policy would delete every version")
+	}
+
+	list, err := ListVersions(client, org, app)
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := make(map[string]bool)
+	if policy.KeepInUse {
+		usages, err := Usages(client, org, app, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range usages {
+			inUse[u.Version] = true
+		}
+	}
+
+	report := &PruneReport{
+		Kept:    make([]*Version, 0),
+		Deleted: make([]*Version, 0),
+		DryRun:  policy.DryRun,
+	}
+
+	now := time.Now()
+	var doomed []*Version
+
+	for i, v := range list {
+		keep := (policy.KeepLast > 0 && i >= len(list)-policy.KeepLast) ||
+			(policy.KeepTagged && v.Tag != "") ||
+			(policy.KeepNewerThan > 0 && now.Sub(v.Created) < policy.KeepNewerThan) ||
+			inUse[v.ID]
+		if keep {
+			report.Kept = append(report.Kept, v)
+		} else {
+			doomed = append(doomed, v)
+		}
+	}
+
+	if policy.DryRun {
+		report.Deleted = append(report.Deleted, doomed...)
+		return report, nil
+	}
+
+	for _, v := range doomed {
+		err = deleteVersion(client, org, app, v.ID)
+		if err != nil {
+			return report, err
+		}
+		report.Deleted = append(report.Deleted, v)
+	}
+
+	return report, nil
+}
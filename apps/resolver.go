@@ -0,0 +1,107 @@
+package apps
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// Resolver resolves app versions to version IDs like ResolveVersionToID, but
+// caches successful results for a fixed time, and merges concurrent identical
+// lookups into a single request. It is safe for concurrent use.
+type Resolver struct {
+	client *api.Client
+	ttl    time.Duration
+
+	lock     sync.Mutex
+	cache    map[resolverKey]*resolverEntry
+	inflight map[resolverKey]*resolverCall
+}
+
+type resolverKey struct {
+	org, app, version string
+}
+
+type resolverEntry struct {
+	id      string
+	expires time.Time
+}
+
+type resolverCall struct {
+	done chan struct{}
+	id   string
+	err  error
+}
+
+// NewResolver returns a Resolver that caches results for ttl.
+func NewResolver(client *api.Client, ttl time.Duration) *Resolver {
+	return &Resolver{
+		client:   client,
+		ttl:      ttl,
+		cache:    make(map[resolverKey]*resolverEntry),
+		inflight: make(map[resolverKey]*resolverCall),
+	}
+}
+
+// Resolve resolves the provided version for the named app, converting it to a
+// version ID. See ResolveVersionToID.
+func (r *Resolver) Resolve(org, app, version string) (string, error) {
+
+	key := resolverKey{org: org, app: app, version: version}
+
+	r.lock.Lock()
+
+	if e, ok := r.cache[key]; ok {
+		if time.Now().Before(e.expires) {
+			r.lock.Unlock()
+			return e.id, nil
+		}
+		delete(r.cache, key)
+	}
+
+	if c, ok := r.inflight[key]; ok {
+		r.lock.Unlock()
+		<-c.done
+		return c.id, c.err
+	}
+
+	c := &resolverCall{done: make(chan struct{})}
+	r.inflight[key] = c
+	r.lock.Unlock()
+
+	c.id, c.err = ResolveVersionToID(r.client, org, app, version)
+
+	r.lock.Lock()
+	delete(r.inflight, key)
+	if c.err == nil {
+		r.cache[key] = &resolverEntry{
+			id:      c.id,
+			expires: time.Now().Add(r.ttl),
+		}
+	}
+	r.lock.Unlock()
+
+	close(c.done)
+
+	return c.id, c.err
+}
+
+// Invalidate discards every cached result for the named app, so that the next
+// lookup for it goes to VMS. Lookups already in progress are unaffected.
+func (r *Resolver) Invalidate(org, app string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for key := range r.cache {
+		if key.org == org && key.app == app {
+			delete(r.cache, key)
+		}
+	}
+}
+
+// InvalidateAll discards every cached result.
+func (r *Resolver) InvalidateAll() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cache = make(map[resolverKey]*resolverEntry)
+}
@@ -0,0 +1,125 @@
+package apps
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// DefaultPageSize is the number of results requested per page when no page
+// size is given.
+const DefaultPageSize = 100
+
+// SearchFilters narrows the results of Search. Type, if not empty, restricts
+// the results to objects of that type. Tag, if not empty, restricts them to
+// apps with a version carrying that tag. Dir, if not empty, restricts them to
+// objects within that directory or its subdirectories.
+//
+// Page selects which page of results to return, starting from zero, and
+// PageSize the number of results per page, which defaults to
+// DefaultPageSize.
+type SearchFilters struct {
+	Type     EntryType
+	Tag      string
+	Dir      string
+	Page     int
+	PageSize int
+}
+
+// SearchResults is a single page of results from Search. Total is the number
+// of matching objects across all pages.
+type SearchResults struct {
+	Entries  []*Entry
+	Page     int
+	PageSize int
+	Total    int
+}
+
+// More reports whether there are further pages of results after this one.
+func (r *SearchResults) More() bool {
+	return (r.Page+1)*r.PageSize < r.Total
+}
+
+type searchResponse struct {
+	Results []appsTuple `json:"results"`
+	Total   int         `json:"total"`
+}
+
+// Search returns a page of objects within the organization's repository whose
+// names contain the query, which is not case sensitive. filters may be nil.
+func Search(client *api.Client, org, query string, filters *SearchFilters) (*SearchResults, error) {
+
+	if filters == nil {
+		filters = new(SearchFilters)
+	}
+
+	size := filters.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+
+	values := url.Values{}
+	values.Set("q", query)
+	if filters.Type != "" {
+		values.Set("type", string(filters.Type))
+	}
+	if filters.Tag != "" {
+		values.Set("tag", filters.Tag)
+	}
+	if dir := cleanDir(filters.Dir); dir != "" {
+		values.Set("dir", dir)
+	}
+	values.Set("page", strconv.Itoa(filters.Page))
+	values.Set("per_page", strconv.Itoa(size))
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("images/api/v3/orgs/%s/search?%s", org, values.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, api.NewAPIError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	pl := new(searchResponse)
+	err = json.Unmarshal(data, pl)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &SearchResults{
+		Entries:  make([]*Entry, 0, len(pl.Results)),
+		Page:     filters.Page,
+		PageSize: size,
+		Total:    pl.Total,
+	}
+
+	for _, tuple := range pl.Results {
+		results.Entries = append(results.Entries, &Entry{
+			Name: tuple.Name,
+			Path: strings.Trim(tuple.Path, "/"),
+			Type: EntryType(tuple.Type),
+			Size: tuple.Size,
+		})
+	}
+
+	return results, nil
+}
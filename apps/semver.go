@@ -0,0 +1,297 @@
+package apps
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// semver is a parsed semantic version. Missing minor or patch numbers are
+// treated as zero, and recorded in parts.
+type semver struct {
+	major, minor, patch int
+	pre                 []string
+	parts               int
+}
+
+// parseSemver parses a semantic version such as "1.2.3", "v2.0.0-rc.1" or
+// "1.4". A wildcard such as "1.x" is treated like "1". Build metadata is
+// ignored.
+func parseSemver(s string) (*semver, error) {
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+
+	v := new(semver)
+	if i := strings.Index(s, "-"); i >= 0 {
+		if i == len(s)-1 {
+			return nil, fmt.Errorf("invalid version '%s'", s)
+		}
+		v.pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+
+	elems := strings.Split(s, ".")
+	if len(elems) > 3 {
+		return nil, fmt.Errorf("invalid version '%s'", s)
+	}
+
+	nums := []*int{&v.major, &v.minor, &v.patch}
+	v.parts = len(elems)
+	for i, elem := range elems {
+		if elem == "x" || elem == "X" || elem == "*" {
+			v.parts = i
+			break
+		}
+		n, err := strconv.Atoi(elem)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version '%s'", s)
+		}
+		*nums[i] = n
+	}
+
+	return v, nil
+}
+
+// compare returns -1, 0 or 1 depending on whether v is less than, equal to, or
+// greater than x, according to semantic versioning precedence.
+func (v *semver) compare(x *semver) int {
+
+	a := []int{v.major, v.minor, v.patch}
+	b := []int{x.major, x.minor, x.patch}
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A version without a prerelease has higher precedence than one with.
+	switch {
+	case len(v.pre) == 0 && len(x.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(x.pre) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.pre) && i < len(x.pre); i++ {
+		p, q := v.pre[i], x.pre[i]
+		if p == q {
+			continue
+		}
+		pn, perr := strconv.Atoi(p)
+		qn, qerr := strconv.Atoi(q)
+		switch {
+		case perr == nil && qerr == nil:
+			if pn < qn {
+				return -1
+			}
+			return 1
+		case perr == nil:
+			return -1
+		case qerr == nil:
+			return 1
+		case p < q:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	switch {
+	case len(v.pre) < len(x.pre):
+		return -1
+	case len(v.pre) > len(x.pre):
+		return 1
+	}
+	return 0
+}
+
+type comparator struct {
+	op string
+	v  *semver
+}
+
+func (c *comparator) matches(v *semver) bool {
+	n := v.compare(c.v)
+	switch c.op {
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	default:
+		return n == 0
+	}
+}
+
+// Constraint is a semantic version constraint, such as "^1.2", "~1.4.0", or
+// ">=2.0 <3.0". A constraint is a set of ranges separated by "||", any of
+// which may match. Each range is a space-separated list of comparators, all of
+// which must match. Comparators use the operators =, <, <=, >, >=, ^ (same
+// major version, or minor version for 0.x) and ~ (same minor version). A bare
+// version such as "1.2" matches any version beginning with those numbers, and
+// "*" matches everything.
+//
+// Versions with a prerelease, such as "1.2.0-rc.1", only match constraints
+// that themselves mention a prerelease.
+type Constraint struct {
+	ranges [][]*comparator
+	pre    bool
+}
+
+// ParseConstraint parses a semantic version constraint. See Constraint.
+func ParseConstraint(s string) (*Constraint, error) {
+
+	c := new(Constraint)
+	c.pre = strings.Contains(s, "-")
+
+	for _, r := range strings.Split(s, "||") {
+		var list []*comparator
+		for _, field := range strings.Fields(r) {
+			cmps, err := parseComparator(field)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, cmps...)
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("empty range in constraint '%s'", s)
+		}
+		c.ranges = append(c.ranges, list)
+	}
+
+	return c, nil
+}
+
+// parseComparator parses a single comparator, expanding shorthand such as
+// "^1.2" into the simple comparators equivalent to it.
+func parseComparator(s string) ([]*comparator, error) {
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op = prefix
+			break
+		}
+	}
+
+	v, err := parseSemver(strings.TrimPrefix(s, op))
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "^":
+		upper := &semver{major: v.major + 1}
+		if v.major == 0 && v.parts > 1 {
+			upper = &semver{minor: v.minor + 1}
+			if v.minor == 0 && v.parts > 2 {
+				upper = &semver{patch: v.patch + 1}
+			}
+		}
+		return []*comparator{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+
+	case "~":
+		upper := &semver{major: v.major + 1}
+		if v.parts > 1 {
+			upper = &semver{major: v.major, minor: v.minor + 1}
+		}
+		return []*comparator{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+
+	case "", "=":
+		switch v.parts {
+		case 0:
+			return []*comparator{{op: ">=", v: v}}, nil
+		case 1:
+			return []*comparator{{op: ">=", v: v}, {op: "<", v: &semver{major: v.major + 1}}}, nil
+		case 2:
+			return []*comparator{{op: ">=", v: v}, {op: "<", v: &semver{major: v.major, minor: v.minor + 1}}}, nil
+		}
+		return []*comparator{{op: "=", v: v}}, nil
+	}
+
+	return []*comparator{{op: op, v: v}}, nil
+}
+
+// Matches reports whether the version satisfies the constraint. Versions that
+// are not valid semantic versions never match.
+func (c *Constraint) Matches(version string) bool {
+
+	v, err := parseSemver(version)
+	if err != nil {
+		return false
+	}
+
+	return c.matches(v)
+}
+
+func (c *Constraint) matches(v *semver) bool {
+
+	if len(v.pre) > 0 && !c.pre {
+		return false
+	}
+
+	for _, r := range c.ranges {
+		ok := true
+		for _, cmp := range r {
+			if !cmp.matches(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResolveConstraint resolves a semantic version constraint against the tags of
+// the named app, returning the ID of the version with the highest tag that
+// satisfies it. Tags that are not valid semantic versions are ignored. See
+// Constraint for the syntax.
+func ResolveConstraint(client *api.Client, org, app, constraint string) (string, error) {
+
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := listVersions(client, org, app)
+	if err != nil {
+		return "", err
+	}
+
+	var best *semver
+	var id string
+
+	for _, tuple := range v {
+		sv, err := parseSemver(tuple.Tag)
+		if err != nil || !c.matches(sv) {
+			continue
+		}
+		if best == nil || sv.compare(best) > 0 {
+			best = sv
+			id = tuple.Version
+		}
+	}
+
+	if best == nil {
+		return "", ErrVersionNotExists
+	}
+
+	return id, nil
+}
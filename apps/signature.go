@@ -0,0 +1,189 @@
+package apps
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// ErrNoSignature is returned whenever a version of an app has no signature.
+var ErrNoSignature = errors.New("version is not signed")
+
+// ErrBadSignature is returned whenever a version's signature was not made by
+// the expected key over the version's checksum.
+var ErrBadSignature = errors.New("signature verification failed")
+
+// Sign reads a package from r and returns a detached ed25519 signature over its
+// SHA-256 digest, suitable for UploadSignature.
+func Sign(key ed25519.PrivateKey, r io.Reader) ([]byte, error) {
+
+	h := sha256.New()
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ed25519.Sign(key, h.Sum(nil)), nil
+}
+
+func signatureURL(client *api.Client, org, app, id string) string {
+	dir, base := splitPath(app)
+	query := url.Values{}
+	query.Set("op", "signature")
+	query.Set("dir", dir)
+	query.Set("version", id)
+	return client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode())
+}
+
+// UploadSignature stores a detached signature alongside a version of the named
+// app, replacing any existing signature. The version may be a version ID or
+// tag.
+func UploadSignature(client *api.Client, org, app, version string, sig []byte) error {
+
+	id, err := ResolveVersionToID(client, org, app, version)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, signatureURL(client, org, app, id), bytes.NewReader(sig))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// GetSignature returns the detached signature stored alongside a version of the
+// named app, or ErrNoSignature if it has none.
+func GetSignature(client *api.Client, org, app, version string) ([]byte, error) {
+
+	id, err := ResolveVersionToID(client, org, app, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return getSignature(client, org, app, id)
+}
+
+func getSignature(client *api.Client, org, app, id string) ([]byte, error) {
+
+	req, err := http.NewRequest(http.MethodGet, signatureURL(client, org, app, id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNoSignature
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// VerifySignature checks that a version of the named app carries a signature
+// made by the holder of pubkey over the version's SHA-256 checksum, as
+// reported in the app's version listing. It says nothing about the package a
+// later Download returns, which may be verified against a checksum VMS reports
+// with the download; use DownloadVerified to check both against the same
+// digest. The version may be a version ID or tag.
+func VerifySignature(client *api.Client, org, app, version string, pubkey ed25519.PublicKey) error {
+
+	v, err := listVersions(client, org, app)
+	if err != nil {
+		return err
+	}
+
+	tuple, err := v.resolve(version)
+	if err != nil {
+		return err
+	}
+
+	return verifySignature(client, org, app, tuple, pubkey)
+}
+
+// DownloadVerified is like Download, but first checks the version's signature
+// with VerifySignature, and then verifies the package against the same
+// checksum the signature covers. Reading the end of the returned io.ReadCloser
+// returns ErrChecksumMismatch rather than io.EOF if the package does not match,
+// so a package read to the end without error is known to come from the key's
+// holder.
+func DownloadVerified(client *api.Client, org, app, version string, pubkey ed25519.PublicKey) (io.ReadCloser, *PackageInfo, error) {
+
+	v, err := listVersions(client, org, app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tuple, err := v.resolve(version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = verifySignature(client, org, app, tuple, pubkey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return download(client, org, app, tuple, tuple.SHA256)
+}
+
+// verifySignature implements VerifySignature for a resolved version.
+func verifySignature(client *api.Client, org, app string, tuple *versionTuple, pubkey ed25519.PublicKey) error {
+
+	if len(pubkey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid ed25519 public key length %d", len(pubkey))
+	}
+
+	if tuple.SHA256 == "" {
+		return errors.New("version has no checksum to verify")
+	}
+
+	digest, err := hex.DecodeString(tuple.SHA256)
+	if err != nil {
+		return err
+	}
+
+	sig, err := getSignature(client, org, app, tuple.Version)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(pubkey, digest, sig) {
+		return ErrBadSignature
+	}
+
+	return nil
+}
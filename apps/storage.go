@@ -0,0 +1,95 @@
+package apps
+
+import (
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// DirUsage summarizes the storage consumed by a directory of an organization's
+// repository, including everything in its subdirectories.
+type DirUsage struct {
+	Path     string
+	Size     int64
+	Apps     int
+	Versions int
+}
+
+// StorageUsage summarizes the storage consumed by an organization's
+// repository. Dirs maps the path of every directory, including the root
+// directory "", to its usage.
+type StorageUsage struct {
+	Size     int64
+	Apps     int
+	Versions int
+	Dirs     map[string]*DirUsage
+}
+
+// RepositoryUsage walks the organization's repository and summarizes the
+// storage consumed by each directory. It lists the versions of every app, so
+// it may be slow for large repositories.
+func RepositoryUsage(client *api.Client, org string) (*StorageUsage, error) {
+
+	u := &StorageUsage{
+		Dirs: map[string]*DirUsage{
+			"": {Path: ""},
+		},
+	}
+
+	err := Walk(client, org, "", func(entry *Entry) error {
+
+		if entry.IsDir() {
+			u.Dirs[entry.Path] = &DirUsage{Path: entry.Path}
+			return nil
+		}
+
+		if !entry.IsApp() {
+			return nil
+		}
+
+		versions, err := ListVersions(client, org, entry.Path)
+		if err != nil {
+			return err
+		}
+
+		var size int64
+		for _, v := range versions {
+			size += v.Size
+		}
+
+		// Charge the app to its directory and each of its ancestors.
+		dir, _ := splitPath(entry.Path)
+		for {
+			d, ok := u.Dirs[dir]
+			if !ok {
+				d = &DirUsage{Path: dir}
+				u.Dirs[dir] = d
+			}
+			d.Size += size
+			d.Apps++
+			d.Versions += len(versions)
+
+			if dir == "" {
+				break
+			}
+			i := strings.LastIndex(dir, "/")
+			if i < 0 {
+				dir = ""
+			} else {
+				dir = dir[:i]
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	root := u.Dirs[""]
+	u.Size = root.Size
+	u.Apps = root.Apps
+	u.Versions = root.Versions
+
+	return u, nil
+}
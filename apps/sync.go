@@ -0,0 +1,224 @@
+package apps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sisatech/api"
+)
+
+// DefaultPackageExtension is the file extension SyncDir treats as marking a
+// package when none is given in the SyncOptions.
+const DefaultPackageExtension = ".vorteil"
+
+// defaultSyncWorkers is the number of packages SyncDir uploads at once when no
+// limit is given in the SyncOptions.
+const defaultSyncWorkers = 4
+
+// SyncOptions controls SyncDir. Extension is the file extension of package
+// files, and defaults to DefaultPackageExtension. Workers limits how many
+// packages are uploaded at once, and defaults to 4. If Delete is true, apps
+// within the remote directory that have no corresponding local package are
+// deleted along with all of their versions. If DryRun is true, SyncDir only
+// reports what it would do.
+type SyncOptions struct {
+	Extension string
+	Workers   int
+	Delete    bool
+	DryRun    bool
+}
+
+// SyncReport summarizes what SyncDir did, listing apps by their paths within
+// the repository in alphabetical order. Failed maps the apps that could not be
+// synchronized to the reason why.
+type SyncReport struct {
+	Uploaded  []string
+	Unchanged []string
+	Deleted   []string
+	Failed    map[string]error
+}
+
+type syncJob struct {
+	local  string
+	remote string
+}
+
+// SyncDir synchronizes a local directory of packages with a directory of the
+// organization's repository. Every package file found within localDir or its
+// subdirectories corresponds to an app at the same relative path within
+// remoteDir, without the file extension. A package is uploaded as a new
+// version if the app does not exist, or if its checksum differs from that of
+// the app's latest version. opts may be nil.
+//
+// Failures to synchronize individual apps are recorded in the report rather
+// than stopping the sync; SyncDir only returns an error if it could not
+// proceed at all.
+func SyncDir(client *api.Client, org, localDir, remoteDir string, opts *SyncOptions) (*SyncReport, error) {
+
+	if opts == nil {
+		opts = new(SyncOptions)
+	}
+
+	ext := opts.Extension
+	if ext == "" {
+		ext = DefaultPackageExtension
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultSyncWorkers
+	}
+
+	remoteDir = cleanDir(remoteDir)
+
+	var jobs []*syncJob
+	err := filepath.Walk(localDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ext) {
+			return nil
+		}
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, &syncJob{
+			local:  p,
+			remote: path.Join(remoteDir, strings.TrimSuffix(filepath.ToSlash(rel), ext)),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{
+		Uploaded:  make([]string, 0),
+		Unchanged: make([]string, 0),
+		Deleted:   make([]string, 0),
+		Failed:    make(map[string]error),
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	ch := make(chan *syncJob)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range ch {
+				uploaded, err := syncPackage(client, org, job, opts.DryRun)
+				lock.Lock()
+				switch {
+				case err != nil:
+					report.Failed[job.remote] = err
+				case uploaded:
+					report.Uploaded = append(report.Uploaded, job.remote)
+				default:
+					report.Unchanged = append(report.Unchanged, job.remote)
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		ch <- job
+	}
+	close(ch)
+	wg.Wait()
+
+	if opts.Delete {
+		local := make(map[string]bool)
+		for _, job := range jobs {
+			local[job.remote] = true
+		}
+
+		err = Walk(client, org, remoteDir, func(entry *Entry) error {
+			if !entry.IsApp() || local[entry.Path] {
+				return nil
+			}
+			if !opts.DryRun {
+				err := Delete(client, org, entry.Path, true)
+				if err != nil {
+					report.Failed[entry.Path] = err
+					return nil
+				}
+			}
+			report.Deleted = append(report.Deleted, entry.Path)
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrAppNotFound) {
+			return report, err
+		}
+	}
+
+	sort.Strings(report.Uploaded)
+	sort.Strings(report.Unchanged)
+	sort.Strings(report.Deleted)
+
+	return report, nil
+}
+
+// syncPackage uploads a single package if it differs from the latest version
+// of its app, reporting whether it did (or, for a dry run, would have).
+func syncPackage(client *api.Client, org string, job *syncJob, dry bool) (bool, error) {
+
+	f, err := os.Open(job.local)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return false, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	v, err := listVersions(client, org, job.remote)
+	if err != nil && !errors.Is(err, ErrAppNotFound) {
+		return false, err
+	}
+
+	if len(v) > 0 {
+		sort.Stable(v)
+		if strings.EqualFold(v[len(v)-1].SHA256, sum) {
+			return false, nil
+		}
+	}
+
+	if dry {
+		return true, nil
+	}
+
+	dir, _ := splitPath(job.remote)
+	err = CreateDir(client, org, dir)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = Upload(client, org, job.remote, f, nil)
+	if err != nil {
+		return false, fmt.Errorf("upload '%s': %v", job.local, err)
+	}
+
+	return true, nil
+}
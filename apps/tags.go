@@ -0,0 +1,132 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/sisatech/api"
+)
+
+// ErrTagNotExists is returned whenever a tag to be removed is not applied to
+// any version of the app.
+var ErrTagNotExists = errors.New("tag does not exist")
+
+type tagPL struct {
+	Version string `json:"version"`
+}
+
+// SetTag applies the tag to a version of the named app, moving it from
+// whichever version it was previously applied to in a single operation. The
+// version may be a version ID or another tag, and is resolved with
+// ResolveVersionToID.
+func SetTag(client *api.Client, org, app, tag, version string) error {
+
+	if tag == "" {
+		return errors.New("tag must not be empty")
+	}
+
+	id, err := ResolveVersionToID(client, org, app, version)
+	if err != nil {
+		return err
+	}
+
+	pl, err := json.Marshal(&tagPL{Version: id})
+	if err != nil {
+		return err
+	}
+
+	dir, base := splitPath(app)
+
+	query := url.Values{}
+	query.Set("op", "tag")
+	query.Set("dir", dir)
+	query.Set("tag", tag)
+
+	req, err := http.NewRequest(http.MethodPut, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), bytes.NewReader(pl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// RemoveTag removes the tag from whichever version of the named app it is
+// applied to. It returns ErrTagNotExists if the app exists but the tag is not
+// applied to any of its versions.
+func RemoveTag(client *api.Client, org, app, tag string) error {
+
+	dir, base := splitPath(app)
+
+	query := url.Values{}
+	query.Set("op", "tag")
+	query.Set("dir", dir)
+	query.Set("tag", tag)
+
+	req, err := http.NewRequest(http.MethodDelete, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	err = responseError(resp)
+
+	// VMS reports a missing tag the same way as a missing app, so only blame
+	// the tag if the app can still be found.
+	if errors.Is(err, ErrAppNotFound) {
+		_, lerr := listVersions(client, org, app)
+		if lerr == nil {
+			return ErrTagNotExists
+		}
+		if !errors.Is(lerr, ErrAppNotFound) {
+			return lerr
+		}
+	}
+
+	return err
+}
+
+// ListTags returns every tag applied to a version of the named app, mapped to
+// the ID of the version it is applied to.
+func ListTags(client *api.Client, org, app string) (map[string]string, error) {
+
+	v, err := listVersions(client, org, app)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	for _, tuple := range v {
+		if tuple.Tag != "" {
+			tags[tuple.Tag] = tuple.Version
+		}
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,361 @@
+package apps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/sisatech/api"
+)
+
+// DefaultContentType is the content type used for uploaded packages when none
+// is given in the UploadOptions.
+const DefaultContentType = "application/octet-stream"
+
+// DefaultChunkSize is the size of each chunk sent by Upload when none is given
+// in the UploadOptions.
+const DefaultChunkSize = 8 << 20
+
+// defaultUploadRetries is the number of times Upload retries a failed chunk
+// when no limit is given in the UploadOptions.
+const defaultUploadRetries = 3
+
+// UploadOptions controls how Upload publishes a package. ContentType is the
+// content type of the package data, and defaults to DefaultContentType. Tags
+// are applied to the new version once it has been created.
+//
+// Packages are sent in chunks of ChunkSize bytes, which defaults to
+// DefaultChunkSize. A chunk that fails is retried up to Retries times, from
+// whatever offset VMS reports having received; a negative Retries disables
+// retrying. If Progress is not nil it is called after every chunk with the
+// number of bytes VMS has received so far and the total size of the package,
+// which is -1 if it is not yet known.
+//
+// Session resumes an upload that previously failed with an *UploadError,
+// rather than starting a new one. Resuming requires the reader passed to
+// Upload to implement io.Seeker, and to be positioned at the start of the same
+// package as when the upload began.
+type UploadOptions struct {
+	ContentType string
+	Tags        []string
+	ChunkSize   int
+	Retries     int
+	Progress    func(sent, total int64)
+	Session     string
+}
+
+// UploadError is returned by Upload whenever an upload fails after VMS has
+// started receiving it. The upload can be resumed by calling Upload again with
+// the Session in its UploadOptions.
+type UploadError struct {
+	Session string
+	Offset  int64
+	Err     error
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("upload interrupted at byte %d: %v", e.Offset, e.Err)
+}
+
+// Unwrap returns the error that interrupted the upload.
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+type uploadSessionResponse struct {
+	Session string `json:"session"`
+}
+
+type uploadChunkResponse struct {
+	Offset  int64  `json:"offset"`
+	Version string `json:"version"`
+}
+
+// Upload publishes the package read from r as a new version of the app at the
+// given path within the organization's repository, creating the app if
+// necessary. It returns the ID of the new version. opts may be nil.
+//
+// The package is sent in chunks, so that a failure part way through only
+// requires the affected chunk to be sent again. See UploadOptions.
+func Upload(client *api.Client, org, app string, r io.Reader, opts *UploadOptions) (string, error) {
+
+	if opts == nil {
+		opts = new(UploadOptions)
+	}
+
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	retries := opts.Retries
+	if retries == 0 {
+		retries = defaultUploadRetries
+	}
+
+	total := int64(-1)
+	if s, ok := r.(io.Seeker); ok {
+		pos, err := s.Seek(0, io.SeekCurrent)
+		if err == nil {
+			end, err := s.Seek(0, io.SeekEnd)
+			if err != nil {
+				return "", err
+			}
+			_, err = s.Seek(pos, io.SeekStart)
+			if err != nil {
+				return "", err
+			}
+			total = end - pos
+		}
+	}
+
+	var session string
+	var offset int64
+
+	if opts.Session == "" {
+		var err error
+		session, err = startUpload(client, org, app, opts, total)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		s, ok := r.(io.Seeker)
+		if !ok {
+			return "", errors.New("resuming an upload requires a seekable reader")
+		}
+
+		session = opts.Session
+
+		var err error
+		offset, err = uploadOffset(client, org, session)
+		if err != nil {
+			return "", &UploadError{Session: session, Err: err}
+		}
+
+		_, err = s.Seek(offset, io.SeekCurrent)
+		if err != nil {
+			return "", &UploadError{Session: session, Offset: offset, Err: err}
+		}
+	}
+
+	buf := make([]byte, chunkSize)
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		last := false
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			last = true
+		} else if err != nil {
+			return "", &UploadError{Session: session, Offset: offset, Err: err}
+		}
+
+		size := total
+		if last {
+			size = offset + int64(n)
+		}
+
+		version, next, err := sendChunk(client, org, session, offset, buf[:n], size, retries)
+		if err != nil {
+			return "", &UploadError{Session: session, Offset: next, Err: err}
+		}
+		offset = next
+
+		if opts.Progress != nil {
+			opts.Progress(offset, size)
+		}
+
+		if last {
+			if version == "" {
+				return "", &UploadError{Session: session, Offset: offset, Err: errors.New("upload response did not include a version")}
+			}
+			return version, nil
+		}
+	}
+}
+
+// startUpload begins a resumable upload session for a new version of the app,
+// returning the session ID.
+func startUpload(client *api.Client, org, app string, opts *UploadOptions, total int64) (string, error) {
+
+	dir, base := splitPath(app)
+	if base == "" {
+		return "", errors.New("app path must not be empty")
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = DefaultContentType
+	}
+
+	query := url.Values{}
+	query.Set("op", "upload")
+	query.Set("dir", dir)
+	query.Set("resumable", "true")
+	for _, tag := range opts.Tags {
+		query.Add("tag", tag)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Upload-Content-Type", contentType)
+	if total >= 0 {
+		req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(total, 10))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", responseError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	pl := new(uploadSessionResponse)
+	err = json.Unmarshal(data, pl)
+	if err != nil {
+		return "", err
+	}
+
+	if pl.Session == "" {
+		return "", errors.New("upload response did not include a session")
+	}
+
+	return pl.Session, nil
+}
+
+// uploadOffset asks VMS how many bytes of an upload session it has received.
+func uploadOffset(client *api.Client, org, session string) (int64, error) {
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("images/api/v3/orgs/%s/uploads/%s", org, session), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, api.NewAPIError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	pl := new(uploadChunkResponse)
+	err = json.Unmarshal(data, pl)
+	if err != nil {
+		return 0, err
+	}
+
+	return pl.Offset, nil
+}
+
+// sendChunk sends data, which begins at offset within the package, to an
+// upload session. total is the size of the whole package, or -1 if it is not
+// yet known. If sending fails, sendChunk asks VMS how much of the chunk it
+// received and retries from there, up to retries times. It returns the
+// version ID if VMS reports the upload complete, and the new offset.
+func sendChunk(client *api.Client, org, session string, offset int64, data []byte, total int64, retries int) (string, int64, error) {
+
+	start := offset
+	end := offset + int64(len(data))
+
+	for attempt := 0; ; attempt++ {
+
+		version, next, err := putChunk(client, org, session, offset, data[offset-start:], total)
+		if err == nil {
+			return version, next, nil
+		}
+
+		if attempt >= retries {
+			return "", offset, err
+		}
+
+		api.Log.Debug("Upload chunk failed", "session", session, "offset", offset, "err", err)
+
+		received, oerr := uploadOffset(client, org, session)
+		if oerr != nil {
+			return "", offset, err
+		}
+
+		if received < start || received > end {
+			return "", received, fmt.Errorf("server reports unexpected offset %d: %v", received, err)
+		}
+
+		offset = received
+	}
+}
+
+// putChunk sends a single chunk of data, beginning at offset within the
+// package, to an upload session.
+func putChunk(client *api.Client, org, session string, offset int64, data []byte, total int64) (string, int64, error) {
+
+	req, err := http.NewRequest(http.MethodPut, client.URL("images/api/v3/orgs/%s/uploads/%s", org, session), bytes.NewReader(data))
+	if err != nil {
+		return "", offset, err
+	}
+
+	size := "*"
+	if total >= 0 {
+		size = strconv.FormatInt(total, 10)
+	}
+
+	if len(data) == 0 {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes */%s", size))
+	} else {
+		req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", offset, offset+int64(len(data))-1, size))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", offset, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", offset, api.NewAPIError(resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", offset, err
+	}
+
+	pl := new(uploadChunkResponse)
+	err = json.Unmarshal(body, pl)
+	if err != nil {
+		return "", offset, err
+	}
+
+	if pl.Offset == 0 {
+		pl.Offset = offset + int64(len(data))
+	}
+
+	return pl.Version, pl.Offset, nil
+}
@@ -0,0 +1,169 @@
+package apps
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sort"
+
+	"github.com/sisatech/api"
+)
+
+// Usage identifies an instance that is running a version of an app. Instance
+// is the instance's slash-separated path within its deployment.
+type Usage struct {
+	Deployment string
+	Instance   string
+	Version    string
+}
+
+type usageDeploymentPL struct {
+	Name string `json:"name"`
+}
+
+type usageStatePL struct {
+	State struct {
+		Children map[string]json.RawMessage `json:"children"`
+	} `json:"state"`
+}
+
+type usageNodePL struct {
+	VM *struct {
+		App     string `json:"app"`
+		Version string `json:"version"`
+	} `json:"vm"`
+	Subtree *struct {
+		Children map[string]json.RawMessage `json:"children"`
+	} `json:"subtree"`
+	Children map[string]json.RawMessage `json:"children"`
+}
+
+// Usages lists every instance in the organization's deployments that is
+// currently running the named app. If version is not empty, only instances
+// running that version are listed; it may be a version ID or tag. Usages are
+// sorted by deployment and then instance.
+//
+// Usages reads the state of every deployment in the organization, so it may
+// be slow for organizations with many deployments.
+func Usages(client *api.Client, org, app, version string) ([]*Usage, error) {
+
+	id := ""
+	if version != "" {
+		var err error
+		id, err = ResolveVersionToID(client, org, app, version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := getJSON(client, client.URL("deployments/api/v3/orgs/%s/deployments/", org))
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := make([]*usageDeploymentPL, 0)
+	err = json.Unmarshal(data, &deployments)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Usage, 0)
+
+	for _, d := range deployments {
+		data, err := getJSON(client, client.URL("deployments/api/v3/orgs/%s/deployments/%s", org, d.Name))
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			// The deployment was deleted after it was listed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pl := new(usageStatePL)
+		err = json.Unmarshal(data, pl)
+		if err != nil {
+			return nil, err
+		}
+
+		err = findUsages(d.Name, "", pl.State.Children, app, id, &list)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Deployment != list[j].Deployment {
+			return list[i].Deployment < list[j].Deployment
+		}
+		return list[i].Instance < list[j].Instance
+	})
+
+	return list, nil
+}
+
+// findUsages adds every instance within the children of a deployment state
+// subtree that runs the app, and version if it is not empty, to list.
+func findUsages(deployment, prefix string, children map[string]json.RawMessage, app, version string, list *[]*Usage) error {
+
+	for k, v := range children {
+
+		node := new(usageNodePL)
+		err := json.Unmarshal(v, node)
+		if err != nil {
+			return err
+		}
+
+		sub := node.Children
+		if node.Subtree != nil {
+			sub = node.Subtree.Children
+		}
+		if sub != nil {
+			err = findUsages(deployment, prefix+k+"/", sub, app, version, list)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if node.VM == nil || node.VM.App != app {
+			continue
+		}
+
+		if version != "" && node.VM.Version != version {
+			continue
+		}
+
+		*list = append(*list, &Usage{
+			Deployment: deployment,
+			Instance:   prefix + k,
+			Version:    node.VM.Version,
+		})
+	}
+
+	return nil
+}
+
+// getJSON fetches the body of a successful GET request.
+func getJSON(client *api.Client, url string) ([]byte, error) {
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, api.NewAPIError(resp)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
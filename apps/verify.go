@@ -0,0 +1,60 @@
+package apps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned whenever package data does not match the
+// checksum it was expected to have.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Verify reads r to the end and checks that its SHA-256 checksum matches the
+// expected hex-encoded checksum, returning ErrChecksumMismatch if it doesn't.
+func Verify(r io.Reader, expected string) error {
+
+	h := sha256.New()
+	_, err := io.Copy(h, r)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), expected) {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+// verifyingReader checksums data as it is read, and returns
+// ErrChecksumMismatch instead of io.EOF if the data did not match.
+type verifyingReader struct {
+	r        io.ReadCloser
+	h        hash.Hash
+	expected string
+}
+
+func newVerifyingReader(r io.ReadCloser, expected string) *verifyingReader {
+	return &verifyingReader{
+		r:        r,
+		h:        sha256.New(),
+		expected: expected,
+	}
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.h.Write(p[:n])
+	if err == io.EOF && !strings.EqualFold(hex.EncodeToString(v.h.Sum(nil)), v.expected) {
+		return n, ErrChecksumMismatch
+	}
+	return n, err
+}
+
+func (v *verifyingReader) Close() error {
+	return v.r.Close()
+}
@@ -0,0 +1,82 @@
+package apps
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// Version describes a single version of an app. Tag is empty if the version is
+// untagged. Size is the size of the version's package in bytes, and SHA256 its
+// hex-encoded SHA-256 checksum. Deprecated versions carry the message given
+// when they were deprecated in DeprecationMessage.
+type Version struct {
+	ID      string
+	Tag     string
+	Created time.Time
+	Size    int64
+	SHA256  string
+
+	Deprecated         bool
+	DeprecationMessage string
+}
+
+// ListVersions returns every version of the named app, oldest first.
+func ListVersions(client *api.Client, org, app string) ([]*Version, error) {
+
+	v, err := listVersions(client, org, app)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Stable(v)
+
+	list := make([]*Version, len(v))
+	for i := range v {
+		list[i] = v[i].export()
+	}
+
+	return list, nil
+}
+
+func (tuple *versionTuple) export() *Version {
+	return &Version{
+		ID:      tuple.Version,
+		Tag:     tuple.Tag,
+		Created: tuple.Created,
+		Size:    tuple.Size,
+		SHA256:  tuple.SHA256,
+
+		Deprecated:         tuple.Deprecated,
+		DeprecationMessage: tuple.DeprecationMessage,
+	}
+}
+
+// listVersions returns the raw version listing for the named app, fetching as
+// many pages as necessary.
+func listVersions(client *api.Client, org, app string) (versionListResponse, error) {
+
+	dir, base := splitPath(app)
+
+	url := client.URL("images/api/v3/orgs/%s/objects/%s?op=list&dir=%s", org, base, dir)
+
+	v := make(versionListResponse, 0)
+	err := listPages(client, url, func(items []json.RawMessage) error {
+		for _, item := range items {
+			var tuple versionTuple
+			err := json.Unmarshal(item, &tuple)
+			if err != nil {
+				return err
+			}
+			v = append(v, tuple)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	return v, nil
+}
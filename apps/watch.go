@@ -0,0 +1,106 @@
+package apps
+
+import (
+	"context"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// WatchInterval is how often WatchVersions polls VMS for changes.
+var WatchInterval = time.Second * 30
+
+// VersionEventType identifies the kind of change a VersionEvent describes.
+type VersionEventType string
+
+// Types of change reported by WatchVersions.
+const (
+	VersionCreated VersionEventType = "created"
+	VersionTagged  VersionEventType = "tagged"
+	WatchFailed    VersionEventType = "failed"
+)
+
+// VersionEvent describes a change to the versions of an app. For
+// VersionCreated events Version is the new version, and for VersionTagged
+// events it is the version the tag now applies to. For WatchFailed events Err
+// describes why VMS could not be polled; watching continues regardless.
+type VersionEvent struct {
+	Type    VersionEventType
+	App     string
+	Version *Version
+	Err     error
+}
+
+// WatchVersions polls VMS every WatchInterval for changes to the versions of
+// the named app, sending an event on the returned channel whenever a new
+// version is created or a tag is applied to a different version. Versions and
+// tags that exist when WatchVersions is called are not reported. The channel
+// is closed once ctx is done.
+func WatchVersions(ctx context.Context, client *api.Client, org, app string) (<-chan *VersionEvent, error) {
+
+	list, err := ListVersions(client, org, app)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool)
+	tags := make(map[string]string)
+	for _, v := range list {
+		ids[v.ID] = true
+		if v.Tag != "" {
+			tags[v.Tag] = v.ID
+		}
+	}
+
+	ch := make(chan *VersionEvent)
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(WatchInterval)
+		defer ticker.Stop()
+
+		send := func(e *VersionEvent) bool {
+			select {
+			case ch <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			list, err := ListVersions(client, org, app)
+			if err != nil {
+				if !send(&VersionEvent{Type: WatchFailed, App: app, Err: err}) {
+					return
+				}
+				continue
+			}
+
+			for _, v := range list {
+				if !ids[v.ID] {
+					ids[v.ID] = true
+					if !send(&VersionEvent{Type: VersionCreated, App: app, Version: v}) {
+						return
+					}
+				}
+
+				if v.Tag != "" && tags[v.Tag] != v.ID {
+					tags[v.Tag] = v.ID
+					if !send(&VersionEvent{Type: VersionTagged, App: app, Version: v}) {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,202 @@
+package build
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// PollInterval is how often Wait checks on the progress of a build.
+var PollInterval = time.Second * 2
+
+// Status describes how far along a build is.
+type Status string
+
+// Build statuses reported by VMS. Succeeded and Failed are final.
+const (
+	Queued    Status = "queued"
+	Running   Status = "running"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"
+)
+
+// Done reports whether the status is final.
+func (s Status) Done() bool {
+	return s == Succeeded || s == Failed
+}
+
+// ErrBuildFailed is returned by Wait and Run when VMS reports that a build
+// failed.
+type ErrBuildFailed struct {
+	ID      string
+	Message string
+}
+
+func (e *ErrBuildFailed) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("build '%s' failed", e.ID)
+	}
+	return fmt.Sprintf("build '%s' failed: %s", e.ID, e.Message)
+}
+
+// Options controls a remote build. App is the path within the organization's
+// repository the built package is published to as a new version, and Tags are
+// applied to that version. Args are passed to the build tool, and may be nil.
+type Options struct {
+	App  string
+	Tags []string
+	Args map[string]string
+}
+
+// Build describes a remote build. Once it has succeeded, Version is the ID of
+// the version it published to App. Error explains why a failed build failed.
+type Build struct {
+	ID       string    `json:"id"`
+	Status   Status    `json:"status"`
+	App      string    `json:"app"`
+	Version  string    `json:"version"`
+	Error    string    `json:"error"`
+	Created  time.Time `json:"created"`
+	Finished time.Time `json:"finished"`
+}
+
+// Submit uploads a gzipped tar archive of a project's source to the VMS build
+// service, such as one produced by Archive, and queues it to be built. The
+// build runs asynchronously; use Wait to find out how it went.
+func Submit(client *api.Client, org string, src io.Reader, opts *Options) (*Build, error) {
+
+	if opts == nil || opts.App == "" {
+		return nil, errors.New("build must name an app to publish to")
+	}
+
+	query := url.Values{}
+	query.Set("app", opts.App)
+	for _, tag := range opts.Tags {
+		query.Add("tag", tag)
+	}
+	for k, v := range opts.Args {
+		query.Add("arg", k+"="+v)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("builds/api/v3/orgs/%s/builds?%s", org, query.Encode()), src)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		return nil, api.NewAPIError(resp)
+	}
+
+	return readBuild(resp)
+}
+
+// Get returns the current state of a build.
+func Get(client *api.Client, org, id string) (*Build, error) {
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("builds/api/v3/orgs/%s/builds/%s", org, id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, api.NewAPIError(resp)
+	}
+
+	return readBuild(resp)
+}
+
+func readBuild(resp *http.Response) (*Build, error) {
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	b := new(Build)
+	err = json.Unmarshal(data, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Wait polls VMS every PollInterval until the build finishes or ctx is done.
+// It returns an *ErrBuildFailed if the build failed.
+func Wait(ctx context.Context, client *api.Client, org, id string) (*Build, error) {
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		b, err := Get(client, org, id)
+		if err != nil {
+			return nil, err
+		}
+
+		switch b.Status {
+		case Succeeded:
+			return b, nil
+		case Failed:
+			return b, &ErrBuildFailed{ID: b.ID, Message: b.Error}
+		}
+
+		select {
+		case <-ctx.Done():
+			return b, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run submits a project to be built, copies the build's logs to w as they are
+// produced, and waits for the build to finish, returning the ID of the version
+// it published. w may be nil if the logs aren't wanted.
+func Run(ctx context.Context, client *api.Client, org string, src io.Reader, opts *Options, w io.Writer) (string, error) {
+
+	b, err := Submit(client, org, src, opts)
+	if err != nil {
+		return "", err
+	}
+
+	api.Log.Debug("Submitted build", "id", b.ID, "app", opts.App)
+
+	if w != nil {
+		err = Logs(ctx, client, org, b.ID, w)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	b, err = Wait(ctx, client, org, b.ID)
+	if err != nil {
+		return "", err
+	}
+
+	return b.Version, nil
+}
@@ -0,0 +1,40 @@
+package build
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// Logs copies the output of a build to w. If the build is still in progress,
+// Logs follows the output as it is produced, returning once the build finishes
+// or ctx is done.
+func Logs(ctx context.Context, client *api.Client, org, id string, w io.Writer) error {
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("builds/api/v3/orgs/%s/builds/%s/logs?follow=true", org, id), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return api.NewAPIError(resp)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
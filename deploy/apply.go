@@ -0,0 +1,206 @@
+package deploy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sisatech/api"
+	"github.com/sisatech/api/apps"
+	"github.com/sisatech/api/platforms"
+)
+
+// Spec describes the complete desired state of a deployment, as read by Apply.
+// It is decoded from JSON such as:
+//
+//	{
+//		"org": "sisatech",
+//		"name": "website",
+//		"instances": {
+//			"frontend/web": {
+//				"platform": "aws-sydney",
+//				"app": "web/nginx",
+//				"version": "stable",
+//				"count": 3
+//			}
+//		}
+//	}
+//
+// Instance keys are slash-separated paths, as used by Pools. Versions may be
+// version IDs or tags, and are resolved with apps.ResolveVersionToID; an empty
+// version resolves to the newest version of the app. An empty platform is
+// replaced with the organization's default platform. If Count is greater than
+// one, the entry describes that many instances, named by appending "-0", "-1",
+// and so on to its key. An entry may also include a "customization" document,
+// which is passed through to VMS unchanged.
+type Spec struct {
+	Org       string                   `json:"org"`
+	Name      string                   `json:"name"`
+	Instances map[string]*InstanceSpec `json:"instances"`
+}
+
+// InstanceSpec describes one entry in a Spec.
+type InstanceSpec struct {
+	Platform string `json:"platform"`
+	App      string `json:"app"`
+	Version  string `json:"version"`
+	Count    int    `json:"count"`
+
+	Customization json.RawMessage `json:"customization,omitempty"`
+	Ports         []PortMapping   `json:"ports,omitempty"`
+	Network       string          `json:"network,omitempty"`
+}
+
+// Apply reads a Spec from r and converges the deployment it describes to
+// match: the deployment is created if it does not exist, its current goal is
+// compared against the spec, and every instance that needs to be created,
+// updated, or deleted is changed in a single push. It returns the changes that
+// were made.
+func Apply(client *api.Client, r io.Reader) (*GoalDiff, error) {
+	return apply(client, r, false, nil)
+}
+
+// DryRun is like Apply, but only returns the changes Apply would make, without
+// changing anything.
+func DryRun(client *api.Client, r io.Reader) (*GoalDiff, error) {
+	return apply(client, r, true, nil)
+}
+
+// DryRunWithCost is like DryRun, but also annotates the returned diff with the
+// change in projected hourly cost according to pricing.
+func DryRunWithCost(client *api.Client, r io.Reader, pricing Pricing) (*GoalDiff, error) {
+	return apply(client, r, true, pricing)
+}
+
+func apply(client *api.Client, r io.Reader, dry bool, pricing Pricing) (*GoalDiff, error) {
+
+	spec := new(Spec)
+	err := json.NewDecoder(r).Decode(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Org == "" || spec.Name == "" {
+		return nil, errors.New("spec must name an org and a deployment")
+	}
+
+	goal, err := spec.goal(client)
+	if err != nil {
+		return nil, err
+	}
+
+	current := NewDeploymentGoal()
+	exists := true
+	reported := false
+
+	state, err := GetDeployment(client, spec.Org, spec.Name)
+	if errors.Is(err, ErrDeploymentNotFound) {
+		exists = false
+	} else if err != nil {
+		return nil, err
+	} else {
+		current = goalFromState(state)
+		reported = state.goal == nil
+	}
+
+	// Without a stored goal to compare against, only the fields VMS reports in
+	// the state can be compared, or every run would see changes.
+	diff := Diff(current, goal)
+	if reported {
+		diff = diffReported(current, goal)
+	}
+	if pricing != nil {
+		err = diff.AnnotateCost(current, goal, pricing)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if dry || (exists && diff.Empty()) {
+		return diff, nil
+	}
+
+	if !exists {
+		err = CreateDeployment(client, spec.Org, spec.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = goal.Push(client, spec.Org, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return diff, nil
+}
+
+// goal builds the DeploymentGoal described by the spec, resolving versions.
+func (spec *Spec) goal(client *api.Client) (*DeploymentGoal, error) {
+
+	g := NewDeploymentGoal()
+	resolved := make(map[string]string)
+	defaultPlatform := ""
+
+	for id, x := range spec.Instances {
+
+		platform := x.Platform
+		if platform == "" {
+			if defaultPlatform == "" {
+				var err error
+				defaultPlatform, err = platforms.Default(client, spec.Org)
+				if err != nil {
+					return nil, fmt.Errorf("instance '%s': %v", id, err)
+				}
+			}
+			platform = defaultPlatform
+		}
+
+		key := x.App + "@" + x.Version
+		version, ok := resolved[key]
+		if !ok {
+			var err error
+			version, err = apps.ResolveVersionToID(client, spec.Org, x.App, x.Version)
+			if err != nil {
+				return nil, fmt.Errorf("instance '%s': %v", id, err)
+			}
+			resolved[key] = version
+		}
+
+		vm := &VM{
+			Platform:      platform,
+			App:           x.App,
+			Version:       version,
+			Customization: x.Customization,
+			Ports:         x.Ports,
+			Network:       x.Network,
+		}
+
+		ids := []string{id}
+		if x.Count > 1 {
+			ids = make([]string, x.Count)
+			for i := range ids {
+				ids[i] = fmt.Sprintf("%s-%d", id, i)
+			}
+		}
+
+		for _, id := range ids {
+			group, _ := splitPath(id)
+			if !validGroup(id) {
+				return nil, fmt.Errorf("instance '%s': %v", id, ErrInvalidInstanceID)
+			}
+			if g.exists(id) || !g.groupAvailable(group) {
+				return nil, fmt.Errorf("instance '%s' conflicts with another instance", id)
+			}
+			g.attachPath(id, vm)
+		}
+	}
+
+	err := g.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
@@ -0,0 +1,177 @@
+package deploy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sisatech/api"
+)
+
+// fakeVMS serves just enough of the VMS API for Apply: logging in, listing the
+// versions of an app, the organization's default platform, and a single
+// deployment. If storeGoal is true, GET responses include the goal last
+// pushed, as well as the state derived from it. If omitPlatform is true, the
+// state does not report the platform of each instance.
+type fakeVMS struct {
+	storeGoal    bool
+	omitPlatform bool
+
+	lock   sync.Mutex
+	exists bool
+	goal   *DeploymentGoal
+	pushes int
+}
+
+func (f *fakeVMS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	switch {
+	case r.URL.Path == "/auth/api/login":
+		w.Write([]byte(`{"jwt":"test"}`))
+
+	case strings.HasPrefix(r.URL.Path, "/images/"):
+		if r.URL.Query().Get("page") != "0" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"version":"v1","tag":"stable"}]`))
+
+	case r.URL.Path == "/platforms/api/v3/orgs/sisatech/default":
+		w.Write([]byte(`{"name":"kvm"}`))
+
+	case r.URL.Path == "/deployments/api/v3/orgs/sisatech/deployments/website":
+		f.serveDeployment(w, r)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeVMS) serveDeployment(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	case http.MethodPut:
+		f.exists = true
+		f.goal = NewDeploymentGoal()
+
+	case http.MethodPost:
+		if !f.exists {
+			http.NotFound(w, r)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		g := NewDeploymentGoal()
+		err := json.Unmarshal(data, g)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.goal = g
+		f.pushes++
+
+	case http.MethodGet:
+		if !f.exists {
+			http.NotFound(w, r)
+			return
+		}
+
+		children := make(map[string]interface{})
+		for id, vm := range f.goal.instances() {
+			x := map[string]interface{}{
+				"app":     vm.App,
+				"version": vm.Version,
+			}
+			if !f.omitPlatform {
+				x["platform"] = vm.Platform
+			}
+			children[id] = map[string]interface{}{
+				"vm":    x,
+				"state": "running",
+			}
+		}
+
+		pl := map[string]interface{}{
+			"state": map[string]interface{}{"children": children},
+		}
+		if f.storeGoal {
+			pl["goal"] = f.goal
+		}
+
+		data, _ := json.Marshal(pl)
+		w.Write(data)
+	}
+}
+
+const testSpec = `{
+	"org": "sisatech",
+	"name": "website",
+	"instances": {
+		"web": {
+			"app": "web/nginx",
+			"version": "stable",
+			"count": 2,
+			"customization": {"vm": {"ram": "512 MiB", "cpus": 2}},
+			"ports": [{"port": 80}],
+			"network": "public"
+		},
+		"db": {
+			"platform": "aws-sydney",
+			"app": "db/postgres",
+			"version": "v1"
+		}
+	}
+}`
+
+func testApplyConverges(t *testing.T, vms *fakeVMS) {
+
+	srv := httptest.NewServer(vms)
+	defer srv.Close()
+
+	client, err := api.Authenticate(srv.URL, &api.ClientCredentials{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := Apply(client, strings.NewReader(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Create) != 3 || len(diff.Update) != 0 || len(diff.Delete) != 0 {
+		t.Fatalf("first apply: unexpected diff %+v", diff)
+	}
+
+	vm, ok := vms.goal.lookup("web-0")
+	if !ok || vm.Platform != "kvm" {
+		t.Fatalf("first apply: instance without a platform was not given the default: %+v", vm)
+	}
+
+	diff, err = Apply(client, strings.NewReader(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.Empty() {
+		t.Fatalf("second apply: expected empty diff, got %+v", diff)
+	}
+	if vms.pushes != 1 {
+		t.Fatalf("second apply pushed the goal again (%d pushes)", vms.pushes)
+	}
+}
+
+func TestApplyConvergesWithStoredGoal(t *testing.T) {
+	testApplyConverges(t, &fakeVMS{storeGoal: true})
+}
+
+func TestApplyConvergesWithStateOnly(t *testing.T) {
+	testApplyConverges(t, &fakeVMS{})
+}
+
+func TestApplyConvergesWithoutReportedPlatform(t *testing.T) {
+	testApplyConverges(t, &fakeVMS{omitPlatform: true})
+}
@@ -0,0 +1,262 @@
+package deploy
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// Metric names understood by the VMS metric source.
+const (
+	MetricCPU         = "cpu"
+	MetricRequestRate = "requests"
+)
+
+// MetricSource supplies observed load for the instances of a Pool. Metrics
+// returns the current value of the named metric for each of the given instance
+// IDs. Instances missing from the result are treated as having no load.
+type MetricSource interface {
+	Metrics(metric string, ids []string) (map[string]float64, error)
+}
+
+// VMSMetrics is a MetricSource that reads the instance metrics VMS reports for
+// a deployment.
+type VMSMetrics struct {
+	client *api.Client
+	org    string
+	name   string
+}
+
+// NewVMSMetrics returns a MetricSource that reads instance metrics for the
+// named deployment of the given organization from VMS.
+func NewVMSMetrics(client *api.Client, org, name string) *VMSMetrics {
+	return &VMSMetrics{
+		client: client,
+		org:    org,
+		name:   name,
+	}
+}
+
+type metricsPL struct {
+	Instances map[string]float64 `json:"instances"`
+}
+
+// Metrics implements MetricSource.
+func (m *VMSMetrics) Metrics(metric string, ids []string) (map[string]float64, error) {
+
+	req, err := http.NewRequest(http.MethodGet, m.client.URL("deployments/api/v3/orgs/%s/deployments/%s/metrics?metric=%s", m.org, m.name, url.QueryEscape(metric)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	pl := new(metricsPL)
+	err = json.Unmarshal(data, pl)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64)
+	for _, id := range ids {
+		if v, ok := pl.Instances[id]; ok {
+			values[id] = v
+		}
+	}
+
+	return values, nil
+}
+
+// AutoscalerConfig controls an Autoscaler.
+//
+// The Autoscaler sizes the Pool so that the average value of Metric across its
+// instances is as close as possible to Target, within Min and Max. A Max of
+// zero means there is no upper bound. If Scheduler is provided, the bounds of
+// its active scaling window take precedence over Min and Max.
+//
+// ScaleDownDelay is the minimum time after any scaling operation before the
+// Autoscaler will remove instances, to avoid flapping on noisy metrics.
+type AutoscalerConfig struct {
+	Metric         string
+	Target         float64
+	Min            int
+	Max            int
+	Scheduler      *Scheduler
+	ScaleDownDelay time.Duration
+}
+
+// Autoscaler scales the number of instances spawned from a single SpawnArgs
+// within a Pool according to load reported by a MetricSource.
+type Autoscaler struct {
+	pool   *Pool
+	args   *SpawnArgs
+	source MetricSource
+	cfg    AutoscalerConfig
+
+	lock       sync.Mutex
+	stop       chan struct{}
+	lastScaled time.Time
+}
+
+// NewAutoscaler returns an Autoscaler that scales instances spawned from args
+// within the Pool according to the load reported by source. It does nothing
+// until it is started, or until Apply is called.
+func (p *Pool) NewAutoscaler(args *SpawnArgs, source MetricSource, cfg *AutoscalerConfig) (*Autoscaler, error) {
+
+	if cfg.Target <= 0 {
+		return nil, errors.New("autoscaler target must be positive")
+	}
+
+	if cfg.Min < 0 || cfg.Max < 0 || (cfg.Max > 0 && cfg.Max < cfg.Min) {
+		return nil, errors.New("invalid autoscaler bounds")
+	}
+
+	a := new(Autoscaler)
+	a.pool = p
+	a.args = args
+	a.source = source
+	a.cfg = *cfg
+
+	return a, nil
+}
+
+// Desired returns the number of instances the Autoscaler wants at time t, given
+// the current load, along with the number that currently exist.
+func (a *Autoscaler) Desired(t time.Time) (int, int, error) {
+
+	a.pool.statusLock.RLock()
+	ids := a.pool.goal.matching(a.args)
+	a.pool.statusLock.RUnlock()
+
+	current := len(ids)
+	n := current
+
+	if current > 0 {
+		values, err := a.source.Metrics(a.cfg.Metric, ids)
+		if err != nil {
+			return 0, current, err
+		}
+
+		var total float64
+		for _, v := range values {
+			total += v
+		}
+
+		n = int(math.Ceil(total / a.cfg.Target))
+	}
+
+	if n < a.cfg.Min {
+		n = a.cfg.Min
+	}
+	if a.cfg.Max > 0 && n > a.cfg.Max {
+		n = a.cfg.Max
+	}
+	if n == 0 && current == 0 {
+		// With no instances there is no load to measure; keep one
+		// around so that load can be observed.
+		n = 1
+	}
+
+	if a.cfg.Scheduler != nil {
+		n = a.cfg.Scheduler.clamp(t, n)
+	}
+
+	return n, current, nil
+}
+
+// Apply scales the Autoscaler's instances to the number returned by Desired.
+func (a *Autoscaler) Apply(t time.Time) error {
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	n, current, err := a.Desired(t)
+	if err != nil {
+		return err
+	}
+
+	if n == current {
+		return nil
+	}
+
+	if n < current && t.Sub(a.lastScaled) < a.cfg.ScaleDownDelay {
+		return nil
+	}
+
+	err = a.pool.Scale(a.args, n)
+	if err != nil {
+		return err
+	}
+
+	a.lastScaled = t
+	a.pool.emit(EventScaled, "", nil)
+	return nil
+}
+
+// Start applies the Autoscaler immediately and then again every interval until
+// Stop is called. Failures are reported as events on the Pool, and through its
+// Errors channel. Calling Start on a running Autoscaler has no effect.
+func (a *Autoscaler) Start(interval time.Duration) {
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	a.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			err := a.Apply(time.Now())
+			if err != nil {
+				a.pool.emit(EventAutoscaleFailed, "", err)
+				a.pool.fail("autoscale", "", err)
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops an Autoscaler started with Start.
+func (a *Autoscaler) Stop() {
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.stop != nil {
+		close(a.stop)
+		a.stop = nil
+	}
+}
@@ -0,0 +1,91 @@
+package deploy
+
+import (
+	"github.com/sisatech/api"
+)
+
+// Backend is the set of deployment operations a Manager performs against VMS.
+// The default Backend, returned by NewHTTPBackend, calls the VMS deployments
+// API. Alternative implementations, such as the in-memory fake in the
+// deploytest package, allow Pool logic to be exercised without VMS.
+//
+// Operations that need more than the deployment's own state, such as Watch,
+// goal validation and placement by constraints, still use the Manager's
+// api.Client directly.
+type Backend interface {
+	GetDeployment(org, name string) (*DeploymentState, error)
+	CreateDeployment(org, name string) error
+	DeleteDeployment(org, name string) error
+	RenameDeployment(org, name, newName string) error
+	PushGoal(org, name string, g *DeploymentGoal) error
+	GetMetadata(org, name string) (*DeploymentMetadata, error)
+	UpdateMetadata(org, name string, metadata *DeploymentMetadata) error
+	ForceDeleteInstance(org, name, id string) error
+	ForceDeleteDeployment(org, name string) error
+	ListDeployments(org string) ([]*DeploymentInfo, error)
+}
+
+type httpBackend struct {
+	client *api.Client
+}
+
+// NewHTTPBackend returns a Backend that calls the VMS deployments API using
+// the given authenticated api.Client.
+func NewHTTPBackend(client *api.Client) Backend {
+	return &httpBackend{client: client}
+}
+
+func (b *httpBackend) GetDeployment(org, name string) (*DeploymentState, error) {
+	return GetDeployment(b.client, org, name)
+}
+
+func (b *httpBackend) CreateDeployment(org, name string) error {
+	return CreateDeployment(b.client, org, name)
+}
+
+func (b *httpBackend) DeleteDeployment(org, name string) error {
+	return DeleteDeployment(b.client, org, name)
+}
+
+func (b *httpBackend) RenameDeployment(org, name, newName string) error {
+	return RenameDeployment(b.client, org, name, newName)
+}
+
+func (b *httpBackend) PushGoal(org, name string, g *DeploymentGoal) error {
+	return g.Push(b.client, org, name)
+}
+
+func (b *httpBackend) GetMetadata(org, name string) (*DeploymentMetadata, error) {
+	return GetDeploymentMetadata(b.client, org, name)
+}
+
+func (b *httpBackend) UpdateMetadata(org, name string, metadata *DeploymentMetadata) error {
+	return UpdateDeploymentMetadata(b.client, org, name, metadata)
+}
+
+func (b *httpBackend) ForceDeleteInstance(org, name, id string) error {
+	return ForceDeleteInstance(b.client, org, name, id)
+}
+
+func (b *httpBackend) ForceDeleteDeployment(org, name string) error {
+	return ForceDeleteDeployment(b.client, org, name)
+}
+
+func (b *httpBackend) ListDeployments(org string) ([]*DeploymentInfo, error) {
+	return ListDeployments(b.client, org)
+}
+
+// NewDeploymentState returns a DeploymentState reporting the given instances,
+// keyed by their slash-separated paths, and deployment-level URLs. It is
+// intended for Backend implementations that don't talk to VMS.
+func NewDeploymentState(instances map[string]*InstanceStatus, urls []string) *DeploymentState {
+	s := &DeploymentState{
+		children: make(map[string]*InstanceStatus),
+		urls:     make([]string, len(urls)),
+	}
+	for k, v := range instances {
+		s.children[k] = v
+	}
+	copy(s.urls, urls)
+	return s
+}
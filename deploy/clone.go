@@ -0,0 +1,34 @@
+package deploy
+
+// Clone creates a new Pool for the named organization with the given name, and
+// populates it with a copy of this Pool's goal, so that the new deployment runs
+// the same apps and versions on the same platforms, under the same instance
+// IDs. Only the goal is copied: hooks, health probes, and restart policies are
+// not carried over to the new Pool. If the copied goal cannot be pushed, the
+// new deployment is deleted again.
+func (p *Pool) Clone(org, name string) (*Pool, error) {
+
+	if p.mgr.closed {
+		return nil, ErrManagerClosed
+	}
+
+	p.statusLock.RLock()
+	g := p.goal.Copy()
+	p.statusLock.RUnlock()
+
+	n, err := p.mgr.NewPool(org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	n.statusLock.Lock()
+	err = n.push(g)
+	n.statusLock.Unlock()
+
+	if err != nil {
+		n.Close()
+		return nil, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,209 @@
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sisatech/api"
+	"github.com/sisatech/api/platforms"
+)
+
+// Pricing supplies the prices used to estimate the cost of a deployment.
+// InstanceCost returns the hourly cost of running a single VM.
+type Pricing interface {
+	InstanceCost(vm *VM) (float64, error)
+}
+
+// PlatformRate describes how a single platform charges for instances: a flat
+// hourly rate per instance, plus hourly rates per CPU and per GiB of memory.
+type PlatformRate struct {
+	Hourly float64
+	PerCPU float64
+	PerGiB float64
+}
+
+// PlatformPricing is a Pricing that charges instances according to the
+// PlatformRate of the platform they run on. Resource usage is read from the
+// "vm" section of each instance's customization document, using its "cpus"
+// and "ram" fields; instances that don't set them are charged for one CPU and
+// no memory. An instance on a platform with no rate is an error.
+type PlatformPricing map[string]*PlatformRate
+
+// FetchPricing builds a PlatformPricing from the pricing metadata VMS holds for
+// each of the organization's platforms. See platforms.Costs. Instance sizes are
+// not considered, only each platform's general rates.
+func FetchPricing(client *api.Client, org string) (PlatformPricing, error) {
+
+	list, err := platforms.List(client, org)
+	if err != nil {
+		return nil, err
+	}
+
+	pp := make(PlatformPricing)
+	for _, p := range list {
+		costs, err := platforms.Costs(client, org, p.Name)
+		if err != nil {
+			return nil, err
+		}
+		pp[p.Name] = &PlatformRate{
+			Hourly: costs.Hourly,
+			PerCPU: costs.PerCPU,
+			PerGiB: costs.PerGiB,
+		}
+	}
+
+	return pp, nil
+}
+
+type costCustomizationPL struct {
+	VM struct {
+		CPUs int             `json:"cpus"`
+		RAM  json.RawMessage `json:"ram"`
+	} `json:"vm"`
+}
+
+// InstanceCost implements Pricing.
+func (pp PlatformPricing) InstanceCost(vm *VM) (float64, error) {
+
+	rate, ok := pp[vm.Platform]
+	if !ok {
+		return 0, fmt.Errorf("no pricing for platform '%s'", vm.Platform)
+	}
+
+	cpus := 1
+	var gib float64
+
+	if len(vm.Customization) > 0 {
+		pl := new(costCustomizationPL)
+		err := json.Unmarshal(vm.Customization, pl)
+		if err != nil {
+			return 0, err
+		}
+
+		if pl.VM.CPUs > 0 {
+			cpus = pl.VM.CPUs
+		}
+
+		if len(pl.VM.RAM) > 0 {
+			gib, err = parseGiB(pl.VM.RAM)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return rate.Hourly + float64(cpus)*rate.PerCPU + gib*rate.PerGiB, nil
+}
+
+// parseGiB converts a memory size to GiB. The size may be a plain number of
+// MiB, or a string such as "512 MiB" or "2 GiB".
+func parseGiB(data json.RawMessage) (float64, error) {
+
+	var mib float64
+	if err := json.Unmarshal(data, &mib); err == nil {
+		return mib / 1024, nil
+	}
+
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ram size: %s", data)
+	}
+
+	s = strings.TrimSpace(s)
+	units := map[string]float64{
+		"kib": 1.0 / (1024 * 1024),
+		"mib": 1.0 / 1024,
+		"gib": 1,
+		"kb":  1.0 / (1024 * 1024),
+		"mb":  1.0 / 1024,
+		"gb":  1,
+	}
+
+	lower := strings.ToLower(s)
+	for suffix, scale := range units {
+		if strings.HasSuffix(lower, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSpace(s[:len(s)-len(suffix)]), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid ram size: '%s'", s)
+			}
+			return n * scale, nil
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ram size: '%s'", s)
+	}
+
+	return n / 1024, nil
+}
+
+// CostEstimate is the projected hourly cost of a goal, in whatever currency
+// the Pricing used to produce it is expressed in.
+type CostEstimate struct {
+	Total     float64
+	Instances map[string]float64
+	Platforms map[string]float64
+}
+
+// EstimateCost projects the hourly cost of running every instance of g.
+func EstimateCost(g *DeploymentGoal, pricing Pricing) (*CostEstimate, error) {
+
+	e := &CostEstimate{
+		Instances: make(map[string]float64),
+		Platforms: make(map[string]float64),
+	}
+
+	for id, vm := range g.instances() {
+		cost, err := pricing.InstanceCost(vm)
+		if err != nil {
+			return nil, fmt.Errorf("instance '%s': %v", id, err)
+		}
+		e.Instances[id] = cost
+		e.Platforms[vm.Platform] += cost
+		e.Total += cost
+	}
+
+	return e, nil
+}
+
+// EstimateCost projects the hourly cost of running the Pool's current goal.
+func (p *Pool) EstimateCost(pricing Pricing) (*CostEstimate, error) {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+	return EstimateCost(p.goal, pricing)
+}
+
+// CostDelta describes how the projected hourly cost of a deployment changes
+// between two goals.
+type CostDelta struct {
+	Before *CostEstimate
+	After  *CostEstimate
+	Delta  float64
+}
+
+// AnnotateCost sets the diff's Cost to the change in projected hourly cost
+// between from and to, which should be the goals the diff was computed from.
+func (d *GoalDiff) AnnotateCost(from, to *DeploymentGoal, pricing Pricing) error {
+
+	before, err := EstimateCost(from, pricing)
+	if err != nil {
+		return err
+	}
+
+	after, err := EstimateCost(to, pricing)
+	if err != nil {
+		return err
+	}
+
+	d.Cost = &CostDelta{
+		Before: before,
+		After:  after,
+		Delta:  after.Total - before.Total,
+	}
+
+	return nil
+}
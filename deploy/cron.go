@@ -0,0 +1,139 @@
+package deploy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron-like expression describing a set of minutes. It
+// uses the traditional five fields: minute, hour, day of month, month, and day
+// of week (0 is Sunday). Each field may be "*", a single value, a range such as
+// "9-17", a step such as "*/15" or "0-30/10", or a comma-separated list of any
+// of these. As with cron, if both the day of month and day of week fields are
+// restricted, a time matches if either of them matches.
+type Schedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	domAny bool
+	dowAny bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// ParseSchedule parses a cron-like expression into a Schedule.
+func ParseSchedule(expr string) (*Schedule, error) {
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("schedule '%s' must have %d fields", expr, len(cronFields))
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, f := range fields {
+		set, err := parseCronField(f, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("schedule '%s': %v", expr, err)
+		}
+		sets[i] = set
+	}
+
+	s := &Schedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}
+
+	return s, nil
+}
+
+func parseCronField(s string, f cronField) (uint64, error) {
+
+	var set uint64
+
+	for _, part := range strings.Split(s, ",") {
+
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step in %s field '%s'", f.name, s)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := f.min, f.max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			lo, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf("bad value in %s field '%s'", f.name, s)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				hi, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return 0, fmt.Errorf("bad value in %s field '%s'", f.name, s)
+				}
+			} else if step > 1 {
+				hi = f.max
+			}
+		}
+
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%s field '%s' out of range %d-%d", f.name, s, f.min, f.max)
+		}
+
+		for i := lo; i <= hi; i += step {
+			set |= 1 << uint(i)
+		}
+	}
+
+	return set, nil
+}
+
+// Matches reports whether the minute containing t is part of the Schedule.
+func (s *Schedule) Matches(t time.Time) bool {
+
+	if s.minute&(1<<uint(t.Minute())) == 0 ||
+		s.hour&(1<<uint(t.Hour())) == 0 ||
+		s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
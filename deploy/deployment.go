@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/sisatech/api"
 )
@@ -16,23 +17,124 @@ type VM struct {
 	Platform string
 	App      string
 	Version  string
+
+	// Labels are recorded locally by the Pool and are not sent to VMS, so
+	// they are lost when a Pool is opened or observed from existing state.
+	Labels map[string]string
+
+	// Draining instances keep running, but VMS stops routing new traffic to
+	// them.
+	Draining bool
+
+	// Customization is the instance's VMS customization document, which can
+	// override settings such as networking, environment variables, and
+	// mounts. It is sent to VMS as-is, and may be nil.
+	Customization json.RawMessage
+
+	// Ports lists the instance's ports to expose, and Network optionally
+	// names the platform network to attach it to.
+	Ports   []PortMapping
+	Network string
+}
+
+// PortMapping exposes one of an instance's ports. Protocol is "tcp" or "udp",
+// and defaults to "tcp" if empty. External requests a specific external port;
+// if it is zero, VMS assigns one.
+type PortMapping struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol,omitempty"`
+	External int    `json:"external,omitempty"`
+}
+
+// PortStatus reports the external address through which one of an instance's
+// ports can be reached.
+type PortStatus struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	External int    `json:"external"`
+	Address  string `json:"address"`
+}
+
+// NodeType returns "vm".
+func (x *VM) NodeType() string {
+	return "vm"
 }
 
 // MarshalJSON TODO
 func (x *VM) MarshalJSON() ([]byte, error) {
+	var customization interface{}
+	if len(x.Customization) > 0 {
+		customization = x.Customization
+	}
+
 	m := map[string]interface{}{
 		"platform":      x.Platform,
 		"app":           x.App,
 		"type":          "vm",
 		"version":       x.Version,
-		"customization": nil,
+		"customization": customization,
+	}
+	if x.Draining {
+		m["draining"] = true
+	}
+	if len(x.Ports) > 0 {
+		m["ports"] = x.Ports
+	}
+	if x.Network != "" {
+		m["network"] = x.Network
 	}
 	return json.Marshal(&m)
 }
 
+type vmPL struct {
+	Platform string `json:"platform"`
+	App      string `json:"app"`
+	Version  string `json:"version"`
+	Draining bool   `json:"draining"`
+
+	Customization json.RawMessage `json:"customization"`
+	Ports         []PortMapping   `json:"ports"`
+	Network       string          `json:"network"`
+}
+
+// UnmarshalJSON ..
+func (x *VM) UnmarshalJSON(data []byte) error {
+
+	pl := new(vmPL)
+	err := json.Unmarshal(data, pl)
+	if err != nil {
+		return err
+	}
+
+	x.Platform = pl.Platform
+	x.App = pl.App
+	x.Version = pl.Version
+	x.Draining = pl.Draining
+	x.Ports = pl.Ports
+	x.Network = pl.Network
+	x.Customization = nil
+	if len(pl.Customization) > 0 && string(pl.Customization) != "null" {
+		x.Customization = pl.Customization
+	}
+
+	return nil
+}
+
 // DeploymentGoal TODO
 type DeploymentGoal struct {
-	children map[string]*VM
+	children map[string]Node
+}
+
+// NewDeploymentGoal returns an empty DeploymentGoal.
+func NewDeploymentGoal() *DeploymentGoal {
+	g := new(DeploymentGoal)
+	g.children = make(map[string]Node)
+	return g
+}
+
+// NodeType returns "subtree".
+func (g *DeploymentGoal) NodeType() string {
+	return "subtree"
 }
 
 // Attach TODO
@@ -42,9 +144,48 @@ func (g *DeploymentGoal) Attach(id string, vm *VM) {
 
 // Detach TODO
 func (g *DeploymentGoal) Detach(id string) {
+	if _, ok := g.children[id].(*VM); ok {
+		delete(g.children, id)
+	}
+}
+
+// AttachGroup attaches a child subtree to the goal under the given ID,
+// replacing any existing node with the same ID.
+func (g *DeploymentGoal) AttachGroup(id string, group *DeploymentGoal) {
+	g.children[id] = group
+}
+
+// DetachGroup removes the child subtree with the given ID, along with
+// everything attached beneath it.
+func (g *DeploymentGoal) DetachGroup(id string) {
+	if _, ok := g.children[id].(*DeploymentGoal); ok {
+		delete(g.children, id)
+	}
+}
+
+// Group returns the child subtree attached under the given ID, or nil if there
+// is no such subtree.
+func (g *DeploymentGoal) Group(id string) *DeploymentGoal {
+	group, _ := g.children[id].(*DeploymentGoal)
+	return group
+}
+
+// AttachNode attaches a node of any type to the goal under the given ID,
+// replacing any existing node with the same ID.
+func (g *DeploymentGoal) AttachNode(id string, node Node) {
+	g.children[id] = node
+}
+
+// DetachNode removes the node with the given ID, whatever its type.
+func (g *DeploymentGoal) DetachNode(id string) {
 	delete(g.children, id)
 }
 
+// Node returns the node attached under the given ID, or nil if there is none.
+func (g *DeploymentGoal) Node(id string) Node {
+	return g.children[id]
+}
+
 // MarshalJSON TODO
 func (g *DeploymentGoal) MarshalJSON() ([]byte, error) {
 
@@ -58,11 +199,44 @@ func (g *DeploymentGoal) MarshalJSON() ([]byte, error) {
 	return []byte(s), nil
 }
 
+type goalPL struct {
+	Type     string                     `json:"type"`
+	Children map[string]json.RawMessage `json:"children"`
+}
+
+// UnmarshalJSON ..
+func (g *DeploymentGoal) UnmarshalJSON(data []byte) error {
+
+	pl := new(goalPL)
+	err := json.Unmarshal(data, pl)
+	if err != nil {
+		return err
+	}
+
+	if pl.Type != "subtree" {
+		return fmt.Errorf("unexpected node type '%s'", pl.Type)
+	}
+
+	g.children = make(map[string]Node)
+
+	for k, v := range pl.Children {
+		node, err := unmarshalNode(v)
+		if err != nil {
+			return err
+		}
+		g.children[k] = node
+	}
+
+	return nil
+}
+
 // Copy TODO
 func (g *DeploymentGoal) Copy() *DeploymentGoal {
-	n := new(DeploymentGoal)
-	n.children = make(map[string]*VM)
+	n := NewDeploymentGoal()
 	for k, v := range g.children {
+		if group, ok := v.(*DeploymentGoal); ok {
+			v = group.Copy()
+		}
 		n.children[k] = v
 	}
 	return n
@@ -89,8 +263,16 @@ func (g *DeploymentGoal) Push(client *api.Client, org, name string) error {
 		defer resp.Body.Close()
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		return responseError(resp)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return errors.New(resp.Status)
+		return &ErrPushRejected{
+			Org:  org,
+			Name: name,
+			Err:  api.NewAPIError(resp),
+		}
 	}
 
 	return nil
@@ -99,6 +281,25 @@ func (g *DeploymentGoal) Push(client *api.Client, org, name string) error {
 // DeploymentState TODO
 type DeploymentState struct {
 	children map[string]*InstanceStatus
+	urls     []string
+	goal     *DeploymentGoal
+}
+
+// Goal returns a copy of the goal VMS reported storing for the deployment
+// alongside its state, or nil if it did not report one.
+func (s *DeploymentState) Goal() *DeploymentGoal {
+	if s.goal == nil {
+		return nil
+	}
+	return s.goal.Copy()
+}
+
+// URLs returns the deployment-level URLs reported by VMS, which load balance
+// across the deployment's instances.
+func (s *DeploymentState) URLs() []string {
+	list := make([]string, len(s.urls))
+	copy(list, s.urls)
+	return list
 }
 
 type statePL struct {
@@ -131,20 +332,55 @@ func (s *DeploymentState) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	s.urls = pl.URLs
 	s.children = make(map[string]*InstanceStatus)
-	for k, v := range pl.Children {
+
+	s.goal = nil
+	if v, ok := m["goal"]; ok && v != nil {
+		data, err = json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		s.goal = NewDeploymentGoal()
+		err = json.Unmarshal(data, s.goal)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.parseChildren("", pl.Children)
+}
+
+// parseChildren adds the instances described by the children of a state
+// subtree to the DeploymentState. Instances within nested subtrees are keyed by
+// their slash-separated path, relative to the root of the deployment.
+func (s *DeploymentState) parseChildren(prefix string, children map[string]interface{}) error {
+
+	for k, v := range children {
 
 		x, ok := v.(map[string]interface{})
 		if !ok {
 			return errors.New("bad json")
 		}
 
+		if sub, ok := x["subtree"].(map[string]interface{}); ok {
+			x = sub
+		}
+
+		if sub, ok := x["children"].(map[string]interface{}); ok {
+			err := s.parseChildren(prefix+k+"/", sub)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
 		v, ok = x["vm"]
 		if !ok {
 			return errors.New("bad json")
 		}
 
-		data, err = json.Marshal(v)
+		data, err := json.Marshal(v)
 		if err != nil {
 			return err
 		}
@@ -155,7 +391,22 @@ func (s *DeploymentState) UnmarshalJSON(data []byte) error {
 			return err
 		}
 
-		s.children[k] = i
+		// Lifecycle information may be reported alongside the "vm" object
+		// rather than within it.
+		if i.State == "" {
+			i.State, _ = x["state"].(string)
+		}
+		if i.Reason == "" {
+			i.Reason, _ = x["reason"].(string)
+		}
+		if i.Reason == "" {
+			i.Reason, _ = x["error"].(string)
+		}
+		if c, ok := x["created"].(string); ok && i.Created.IsZero() {
+			i.Created, _ = time.Parse(time.RFC3339, c)
+		}
+
+		s.children[prefix+k] = i
 	}
 
 	return nil
@@ -179,7 +430,7 @@ func GetDeployment(client *api.Client, org, name string) (*DeploymentState, erro
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(resp.Status)
+		return nil, responseError(resp)
 	}
 
 	pl, err := ioutil.ReadAll(resp.Body)
@@ -216,7 +467,7 @@ func CreateDeployment(client *api.Client, org, name string) error {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return errors.New(resp.Status)
+		return responseError(resp)
 	}
 
 	return nil
@@ -240,7 +491,224 @@ func DeleteDeployment(client *api.Client, org, name string) error {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return errors.New(resp.Status)
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+type renamePL struct {
+	Name string `json:"name"`
+}
+
+// RenameDeployment renames the named deployment within the named organization
+// to newName, using the provided api.Client. The deployment's instances are not
+// affected.
+func RenameDeployment(client *api.Client, org, name, newName string) error {
+
+	data, err := json.Marshal(&renamePL{Name: newName})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("deployments/api/v3/orgs/%s/deployments/%s/rename", org, name), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// DeploymentMetadata contains descriptive information about a deployment that
+// does not affect its instances. Labels are arbitrary key-value pairs, used by
+// the Manager to record which Manager created a deployment and when.
+type DeploymentMetadata struct {
+	Description string            `json:"description"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// Copy returns a deep copy of the metadata.
+func (md *DeploymentMetadata) Copy() *DeploymentMetadata {
+	n := new(DeploymentMetadata)
+	n.Description = md.Description
+	if md.Labels != nil {
+		n.Labels = make(map[string]string)
+		for k, v := range md.Labels {
+			n.Labels[k] = v
+		}
+	}
+	return n
+}
+
+// GetDeploymentMetadata returns the metadata of the named deployment for the
+// named organization, using the provided api.Client.
+func GetDeploymentMetadata(client *api.Client, org, name string) (*DeploymentMetadata, error) {
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("deployments/api/v3/orgs/%s/deployments/%s/metadata", org, name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	pl, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	md := new(DeploymentMetadata)
+	err = json.Unmarshal(pl, md)
+	if err != nil {
+		return nil, err
+	}
+
+	return md, nil
+}
+
+// DeploymentInfo summarizes a deployment, as returned by ListDeployments.
+type DeploymentInfo struct {
+	Name     string              `json:"name"`
+	Metadata *DeploymentMetadata `json:"metadata"`
+}
+
+// ListDeployments returns a summary of every deployment belonging to the named
+// organization, using the provided api.Client.
+func ListDeployments(client *api.Client, org string) ([]*DeploymentInfo, error) {
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("deployments/api/v3/orgs/%s/deployments/", org), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	pl, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*DeploymentInfo, 0)
+	err = json.Unmarshal(pl, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, x := range list {
+		if x.Metadata == nil {
+			x.Metadata = new(DeploymentMetadata)
+		}
+	}
+
+	return list, nil
+}
+
+// UpdateDeploymentMetadata replaces the metadata of the named deployment for
+// the named organization, using the provided api.Client.
+func UpdateDeploymentMetadata(client *api.Client, org, name string, metadata *DeploymentMetadata) error {
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, client.URL("deployments/api/v3/orgs/%s/deployments/%s/metadata", org, name), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// ForceDeleteInstance forcibly deletes a single instance of the named
+// deployment for the named organization, using the provided api.Client,
+// without waiting for it to shut down cleanly. The instance is identified by
+// its slash-separated path within the deployment.
+func ForceDeleteInstance(client *api.Client, org, name, id string) error {
+
+	req, err := http.NewRequest(http.MethodDelete, client.URL("deployments/api/v3/orgs/%s/deployments/%s/instances/%s?force=true", org, name, id), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// ForceDeleteDeployment forcibly deletes the named deployment from the named
+// organization using the provided api.Client, without waiting for its
+// instances to shut down cleanly.
+func ForceDeleteDeployment(client *api.Client, org, name string) error {
+
+	req, err := http.NewRequest(http.MethodDelete, client.URL("deployments/api/v3/orgs/%s/deployments/%s?force=true", org, name), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
 	}
 
 	return nil
@@ -0,0 +1,334 @@
+// Package deploytest provides an in-memory deploy.Backend for testing code
+// built on deploy.Manager and deploy.Pool without VMS.
+package deploytest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sisatech/api/deploy"
+)
+
+// Backend is an in-memory implementation of deploy.Backend. Pushed goals take
+// effect immediately: new instances are reported in the InitialState, removed
+// instances disappear, and changed instances are reported afresh. Tests can
+// then move instances through their lifecycle with SetInstanceState, and
+// inject failures with FailNext. The zero value is not usable; use NewBackend.
+type Backend struct {
+	lock        sync.Mutex
+	deployments map[string]*deployment
+	failures    []error
+	nextIP      int
+
+	// InitialState is the state reported for newly pushed instances. It
+	// defaults to "running".
+	InitialState string
+}
+
+type deployment struct {
+	goal      *deploy.DeploymentGoal
+	metadata  *deploy.DeploymentMetadata
+	instances map[string]*deploy.InstanceStatus
+}
+
+// NewBackend returns an empty Backend.
+func NewBackend() *Backend {
+	return &Backend{
+		deployments:  make(map[string]*deployment),
+		InitialState: "running",
+	}
+}
+
+func key(org, name string) string {
+	return org + "/" + name
+}
+
+// FailNext causes the next call to any deploy.Backend method to return err
+// without doing anything. Calling it more than once queues several failures.
+func (b *Backend) FailNext(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.failures = append(b.failures, err)
+}
+
+// prepare returns a queued failure, if there is one, and otherwise the named
+// deployment. The caller must hold the lock.
+func (b *Backend) prepare(org, name string) (*deployment, error) {
+
+	if len(b.failures) > 0 {
+		err := b.failures[0]
+		b.failures = b.failures[1:]
+		return nil, err
+	}
+
+	d, ok := b.deployments[key(org, name)]
+	if !ok {
+		return nil, deploy.ErrDeploymentNotFound
+	}
+
+	return d, nil
+}
+
+// GetDeployment implements deploy.Backend.
+func (b *Backend) GetDeployment(org, name string) (*deploy.DeploymentState, error) {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	d, err := b.prepare(org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make(map[string]*deploy.InstanceStatus)
+	for id, status := range d.instances {
+		x := *status
+		instances[id] = &x
+	}
+
+	return deploy.NewDeploymentState(instances, nil), nil
+}
+
+// CreateDeployment implements deploy.Backend.
+func (b *Backend) CreateDeployment(org, name string) error {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	_, err := b.prepare(org, name)
+	if err == nil {
+		return fmt.Errorf("deployment '%s' already exists", key(org, name))
+	}
+	if err != deploy.ErrDeploymentNotFound {
+		return err
+	}
+
+	b.deployments[key(org, name)] = &deployment{
+		goal:      deploy.NewDeploymentGoal(),
+		metadata:  new(deploy.DeploymentMetadata),
+		instances: make(map[string]*deploy.InstanceStatus),
+	}
+
+	return nil
+}
+
+// DeleteDeployment implements deploy.Backend.
+func (b *Backend) DeleteDeployment(org, name string) error {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	_, err := b.prepare(org, name)
+	if err != nil {
+		return err
+	}
+
+	delete(b.deployments, key(org, name))
+	return nil
+}
+
+// RenameDeployment implements deploy.Backend.
+func (b *Backend) RenameDeployment(org, name, newName string) error {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	d, err := b.prepare(org, name)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := b.deployments[key(org, newName)]; ok {
+		return fmt.Errorf("deployment '%s' already exists", key(org, newName))
+	}
+
+	delete(b.deployments, key(org, name))
+	b.deployments[key(org, newName)] = d
+	return nil
+}
+
+// PushGoal implements deploy.Backend.
+func (b *Backend) PushGoal(org, name string, g *deploy.DeploymentGoal) error {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	d, err := b.prepare(org, name)
+	if err != nil {
+		return err
+	}
+
+	diff := deploy.Diff(d.goal, g)
+	vms := g.Instances()
+
+	for _, id := range diff.Delete {
+		delete(d.instances, id)
+	}
+
+	for _, id := range append(diff.Create, diff.Update...) {
+		vm := vms[id]
+		b.nextIP++
+		d.instances[id] = &deploy.InstanceStatus{
+			Platform: vm.Platform,
+			Deployer: "deploytest",
+			App:      vm.App,
+			Version:  vm.Version,
+			Hostname: id,
+			IP:       fmt.Sprintf("10.0.%d.%d", b.nextIP/256, b.nextIP%256),
+			State:    b.InitialState,
+			Created:  time.Now(),
+		}
+	}
+
+	d.goal = g.Copy()
+	return nil
+}
+
+// GetMetadata implements deploy.Backend.
+func (b *Backend) GetMetadata(org, name string) (*deploy.DeploymentMetadata, error) {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	d, err := b.prepare(org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.metadata.Copy(), nil
+}
+
+// UpdateMetadata implements deploy.Backend.
+func (b *Backend) UpdateMetadata(org, name string, metadata *deploy.DeploymentMetadata) error {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	d, err := b.prepare(org, name)
+	if err != nil {
+		return err
+	}
+
+	d.metadata = metadata.Copy()
+	return nil
+}
+
+// ForceDeleteInstance implements deploy.Backend.
+func (b *Backend) ForceDeleteInstance(org, name, id string) error {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	d, err := b.prepare(org, name)
+	if err != nil {
+		return err
+	}
+
+	delete(d.instances, id)
+	return nil
+}
+
+// ForceDeleteDeployment implements deploy.Backend.
+func (b *Backend) ForceDeleteDeployment(org, name string) error {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	_, err := b.prepare(org, name)
+	if err != nil {
+		return err
+	}
+
+	delete(b.deployments, key(org, name))
+	return nil
+}
+
+// ListDeployments implements deploy.Backend.
+func (b *Backend) ListDeployments(org string) ([]*deploy.DeploymentInfo, error) {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if len(b.failures) > 0 {
+		err := b.failures[0]
+		b.failures = b.failures[1:]
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for k := range b.deployments {
+		if strings.HasPrefix(k, org+"/") {
+			names = append(names, strings.TrimPrefix(k, org+"/"))
+		}
+	}
+	sort.Strings(names)
+
+	list := make([]*deploy.DeploymentInfo, 0, len(names))
+	for _, name := range names {
+		list = append(list, &deploy.DeploymentInfo{
+			Name:     name,
+			Metadata: b.deployments[key(org, name)].metadata.Copy(),
+		})
+	}
+
+	return list, nil
+}
+
+// SetInstanceState changes the state, and failure reason, reported for an
+// instance of the named deployment.
+func (b *Backend) SetInstanceState(org, name, id, state, reason string) error {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	d, ok := b.deployments[key(org, name)]
+	if !ok {
+		return deploy.ErrDeploymentNotFound
+	}
+
+	status, ok := d.instances[id]
+	if !ok {
+		return fmt.Errorf("instance '%s' not found", id)
+	}
+
+	status.State = state
+	status.Reason = reason
+	return nil
+}
+
+// Goal returns a copy of the last goal pushed to the named deployment.
+func (b *Backend) Goal(org, name string) (*deploy.DeploymentGoal, error) {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	d, ok := b.deployments[key(org, name)]
+	if !ok {
+		return nil, deploy.ErrDeploymentNotFound
+	}
+
+	return d.goal.Copy(), nil
+}
+
+// Deployments returns the alphabetized names of every deployment belonging to
+// the named organization.
+func (b *Backend) Deployments(org string) []string {
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	list := make([]string, 0)
+	for k := range b.deployments {
+		if strings.HasPrefix(k, org+"/") {
+			list = append(list, strings.TrimPrefix(k, org+"/"))
+		}
+	}
+
+	sort.Strings(list)
+	return list
+}
+
+var _ deploy.Backend = (*Backend)(nil)
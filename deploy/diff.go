@@ -0,0 +1,109 @@
+package deploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// GoalDiff describes the changes needed to turn one goal into another. Each
+// list contains alphabetized instance paths: Create lists instances only in the
+// new goal, Delete lists instances only in the old goal, and Update lists
+// instances in both whose specifications differ. Cost is nil unless the diff
+// has been annotated with AnnotateCost.
+type GoalDiff struct {
+	Create []string
+	Update []string
+	Delete []string
+	Cost   *CostDelta
+}
+
+// Empty reports whether the diff contains no changes.
+func (d *GoalDiff) Empty() bool {
+	return len(d.Create) == 0 && len(d.Update) == 0 && len(d.Delete) == 0
+}
+
+// Diff compares two goals, returning the changes needed to turn from into to.
+func Diff(from, to *DeploymentGoal) *GoalDiff {
+	return diff(from, to, (*VM).equal)
+}
+
+// diffReported is like Diff, but only compares the fields of each VM that VMS
+// reports in a deployment's state, for when from was reconstructed from one.
+// An empty Platform in from, where the state did not report one, matches any
+// platform.
+func diffReported(from, to *DeploymentGoal) *GoalDiff {
+	return diff(from, to, func(x, y *VM) bool {
+		return (y.Platform == "" || x.Platform == y.Platform) &&
+			x.App == y.App && x.Version == y.Version
+	})
+}
+
+// diff implements Diff, using equal to decide whether a VM in to that is also
+// in from needs updating. It is called with the VM from to first.
+func diff(from, to *DeploymentGoal, equal func(x, y *VM) bool) *GoalDiff {
+
+	d := &GoalDiff{
+		Create: make([]string, 0),
+		Update: make([]string, 0),
+		Delete: make([]string, 0),
+	}
+
+	a := from.instances()
+	b := to.instances()
+
+	for id, x := range b {
+		y, ok := a[id]
+		if !ok {
+			d.Create = append(d.Create, id)
+		} else if !equal(x, y) {
+			d.Update = append(d.Update, id)
+		}
+	}
+
+	for id := range a {
+		if _, ok := b[id]; !ok {
+			d.Delete = append(d.Delete, id)
+		}
+	}
+
+	sort.Strings(d.Create)
+	sort.Strings(d.Update)
+	sort.Strings(d.Delete)
+
+	return d
+}
+
+// equal reports whether two VMs have the same specification as far as VMS is
+// concerned. Local-only fields such as Labels are ignored.
+func (x *VM) equal(y *VM) bool {
+	return x.Platform == y.Platform && x.App == y.App && x.Version == y.Version &&
+		x.Draining == y.Draining && equalJSON(x.Customization, y.Customization) &&
+		x.Network == y.Network && equalPorts(x.Ports, y.Ports)
+}
+
+// equalJSON reports whether two JSON documents have the same content, ignoring
+// formatting and the order of object keys, which VMS does not preserve.
+func equalJSON(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func equalPorts(a, b []PortMapping) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package deploy
+
+import (
+	"time"
+)
+
+// drainPollInterval is how often Drain checks whether an instance has stopped
+// receiving traffic.
+var drainPollInterval = time.Second * 2
+
+// Drain gracefully removes the instance named by id from the Pool. It first
+// marks the instance as draining, so that VMS stops routing new traffic to it,
+// and then waits until VMS no longer reports any URLs for the instance, or
+// until gracePeriod has elapsed, before destroying it. The Pool is not locked
+// while Drain waits, so other operations may proceed in the meantime. If the
+// deployment's state cannot be fetched while waiting, Drain returns the error
+// and leaves the instance draining in the Pool rather than destroying it.
+func (p *Pool) Drain(id string, gracePeriod time.Duration) error {
+
+	err := p.markDraining(id)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(gracePeriod)
+	for time.Now().Before(deadline) {
+
+		state, err := p.mgr.backend.GetDeployment(p.org, p.Name())
+		if err != nil {
+			p.fail("drain", id, err)
+			return err
+		}
+
+		status, ok := state.children[id]
+		if !ok || len(status.URLs) == 0 {
+			break
+		}
+
+		wait := drainPollInterval
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		time.Sleep(wait)
+	}
+
+	return p.Destroy(id)
+}
+
+func (p *Pool) markDraining(id string) error {
+
+	if p.mgr.closed {
+		return ErrManagerClosed
+	}
+
+	p.statusLock.Lock()
+	defer p.unlock()
+
+	vm, ok := p.goal.lookup(id)
+	if !ok {
+		return ErrInstanceNotInPool
+	}
+
+	if vm.Draining {
+		return nil
+	}
+
+	x := *vm
+	x.Draining = true
+
+	g := p.goal.Copy()
+	g.attachPath(id, &x)
+
+	return p.push(g)
+}
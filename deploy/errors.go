@@ -0,0 +1,81 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// ErrDeploymentNotFound is returned whenever VMS reports that a deployment does
+// not exist. The underlying *api.APIError can be retrieved with errors.As.
+var ErrDeploymentNotFound = errors.New("deployment not found")
+
+// ErrPushRejected is returned whenever VMS rejects a goal pushed to a
+// deployment. It wraps the *api.APIError containing the server's explanation.
+type ErrPushRejected struct {
+	Org  string
+	Name string
+	Err  *api.APIError
+}
+
+func (e *ErrPushRejected) Error() string {
+	return fmt.Sprintf("push to deployment '%s/%s' rejected: %v", e.Org, e.Name, e.Err)
+}
+
+// Unwrap returns the underlying *api.APIError.
+func (e *ErrPushRejected) Unwrap() error {
+	return e.Err
+}
+
+// ErrTimeout is returned whenever an operation against VMS takes too long. Op
+// names the operation that timed out.
+type ErrTimeout struct {
+	Op string
+}
+
+func (e *ErrTimeout) Error() string {
+	return fmt.Sprintf("%s timed out", e.Op)
+}
+
+// ErrInstanceFailed is returned whenever VMS reports that an instance being
+// waited on has failed. Reason contains the failure reason given by VMS.
+type ErrInstanceFailed struct {
+	ID     string
+	Reason string
+}
+
+func (e *ErrInstanceFailed) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("instance '%s' failed", e.ID)
+	}
+	return fmt.Sprintf("instance '%s' failed: %s", e.ID, e.Reason)
+}
+
+// notFoundError associates an *api.APIError with ErrDeploymentNotFound.
+type notFoundError struct {
+	err *api.APIError
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrDeploymentNotFound, e.err)
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrDeploymentNotFound
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+// responseError converts an unexpected response from the deployments API into
+// an error.
+func responseError(resp *http.Response) error {
+	e := api.NewAPIError(resp)
+	if resp.StatusCode == http.StatusNotFound {
+		return &notFoundError{err: e}
+	}
+	return e
+}
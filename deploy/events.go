@@ -0,0 +1,59 @@
+package deploy
+
+import (
+	"time"
+)
+
+// eventBufferSize is the number of events a Pool will buffer for a slow
+// consumer before it starts dropping them.
+const eventBufferSize = 64
+
+// EventType identifies the kind of activity an Event describes.
+type EventType string
+
+// Event types emitted by a Pool.
+const (
+	EventProbeFailed   EventType = "probe-failed"
+	EventRestarted     EventType = "restarted"
+	EventRestartFailed EventType = "restart-failed"
+	EventGaveUp        EventType = "gave-up"
+
+	EventScaled          EventType = "scaled"
+	EventScheduleFailed  EventType = "schedule-failed"
+	EventAutoscaleFailed EventType = "autoscale-failed"
+
+	EventIllegalTransition EventType = "illegal-transition"
+)
+
+// Event describes something the Pool did, or observed, on its own initiative
+// rather than as a direct result of a function call.
+type Event struct {
+	Type     EventType
+	Pool     string
+	Instance string
+	Time     time.Time
+	Err      error
+}
+
+// Events returns a channel that receives an Event whenever the Pool performs
+// some automatic action, such as restarting an unhealthy instance. The channel
+// is buffered, and events are dropped rather than blocking the Pool if nobody
+// is reading from it.
+func (p *Pool) Events() <-chan *Event {
+	return p.events
+}
+
+func (p *Pool) emit(typ EventType, id string, err error) {
+	e := &Event{
+		Type:     typ,
+		Pool:     p.key(),
+		Instance: id,
+		Time:     time.Now(),
+		Err:      err,
+	}
+
+	select {
+	case p.events <- e:
+	default:
+	}
+}
@@ -0,0 +1,53 @@
+package deploy
+
+import (
+	"time"
+)
+
+// ErrorEvent describes a failure in an activity that a Pool performs in the
+// background, where there is no caller to return an error to. Op names the
+// activity that failed, such as "restart" or "schedule".
+type ErrorEvent struct {
+	Time     time.Time
+	Pool     string
+	Instance string
+	Op       string
+	Err      error
+}
+
+// Errors returns a channel that receives an ErrorEvent whenever one of the
+// Pool's background activities fails. The channel is buffered, and events are
+// dropped rather than blocking the Pool if nobody is reading from it.
+func (p *Pool) Errors() <-chan *ErrorEvent {
+	return p.errors
+}
+
+// Errors returns a channel that receives the ErrorEvents of every Pool managed
+// by the Manager. Like Pool.Errors, it drops events if nobody is reading from
+// it, and reading from one channel does not drain the other.
+func (m *Manager) Errors() <-chan *ErrorEvent {
+	return m.errors
+}
+
+// fail reports a background failure on the Pool's and the Manager's error
+// channels.
+func (p *Pool) fail(op, id string, err error) {
+
+	e := &ErrorEvent{
+		Time:     time.Now(),
+		Pool:     p.key(),
+		Instance: id,
+		Op:       op,
+		Err:      err,
+	}
+
+	select {
+	case p.errors <- e:
+	default:
+	}
+
+	select {
+	case p.mgr.errors <- e:
+	default:
+	}
+}
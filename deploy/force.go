@@ -0,0 +1,101 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// forceTimeout limits how long each force-delete request may take.
+var forceTimeout = time.Second * 30
+
+// CleanupError is returned by Close whenever a Pool's deployment could not be
+// removed from VMS completely, even after escalating to force-deletion. Pool
+// names the Pool in the form "org::name". Remaining lists every instance that
+// could not be force-deleted, and Errs holds the reason for each, keyed by
+// instance path. If the instances were removed but the deployment itself was
+// not, Remaining is empty and Err explains why. Cause is the failure of the
+// graceful deletion that triggered the escalation.
+type CleanupError struct {
+	Pool      string
+	Remaining []string
+	Errs      map[string]error
+	Err       error
+	Cause     error
+}
+
+func (e *CleanupError) Error() string {
+	if len(e.Remaining) > 0 {
+		return fmt.Sprintf("cleanup of pool %s incomplete: %d instances could not be removed: %v", e.Pool, len(e.Remaining), e.Remaining)
+	}
+	return fmt.Sprintf("cleanup of pool %s incomplete: %v", e.Pool, e.Err)
+}
+
+// Unwrap returns the failure of the graceful deletion.
+func (e *CleanupError) Unwrap() error {
+	return e.Cause
+}
+
+// withTimeout runs fn, giving up with an ErrTimeout naming op if it takes
+// longer than d. fn keeps running in the background after a timeout.
+func withTimeout(op string, d time.Duration, fn func() error) error {
+	ch := make(chan error, 1)
+	go func() {
+		ch <- fn()
+	}()
+	select {
+	case err := <-ch:
+		return err
+	case <-time.After(d):
+		return &ErrTimeout{Op: op}
+	}
+}
+
+// forceDelete force-deletes every instance in the Pool's deployment, and then
+// the deployment itself. The caller must hold the statusLock.
+func (p *Pool) forceDelete(cause error) error {
+
+	backend := p.mgr.backend
+
+	ids := make(map[string]bool)
+	for id := range p.goal.instances() {
+		ids[id] = true
+	}
+	for id := range p.state.children {
+		ids[id] = true
+	}
+
+	e := &CleanupError{
+		Pool:      p.key(),
+		Remaining: make([]string, 0),
+		Errs:      make(map[string]error),
+		Cause:     cause,
+	}
+
+	for id := range ids {
+		id := id
+		err := withTimeout("force delete instance", forceTimeout, func() error {
+			return backend.ForceDeleteInstance(p.org, p.name, id)
+		})
+		if err != nil && !errors.Is(err, ErrDeploymentNotFound) {
+			e.Remaining = append(e.Remaining, id)
+			e.Errs[id] = err
+		}
+	}
+
+	if len(e.Remaining) > 0 {
+		sort.Strings(e.Remaining)
+		return e
+	}
+
+	err := withTimeout("force delete deployment", forceTimeout, func() error {
+		return backend.ForceDeleteDeployment(p.org, p.name)
+	})
+	if err != nil && !errors.Is(err, ErrDeploymentNotFound) {
+		e.Err = err
+		return e
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package deploy
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRevisionNotFound is returned whenever a revision number cannot be found in
+// a Pool's history.
+var ErrRevisionNotFound = errors.New("revision not found in pool history")
+
+// historyLimit is the number of revisions a Pool remembers. Older revisions are
+// forgotten as new ones are pushed.
+const historyLimit = 100
+
+// Revision is a goal that was successfully pushed to VMS by a Pool. Revision
+// numbers start at 1 and increase by one with every push.
+type Revision struct {
+	Number int
+	Time   time.Time
+	Goal   *DeploymentGoal
+}
+
+// record adds g to the Pool's history. The caller must hold the statusLock.
+func (p *Pool) record(g *DeploymentGoal) {
+
+	p.revision++
+	p.history = append(p.history, &Revision{
+		Number: p.revision,
+		Time:   time.Now(),
+		Goal:   g,
+	})
+
+	if len(p.history) > historyLimit {
+		p.history = p.history[len(p.history)-historyLimit:]
+	}
+}
+
+// History returns the goals most recently pushed to VMS by the Pool, oldest
+// first. Only the last 100 revisions are kept. The returned goals are copies,
+// and modifying them has no effect on the Pool.
+func (p *Pool) History() []*Revision {
+
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+
+	list := make([]*Revision, len(p.history))
+	for i, r := range p.history {
+		list[i] = &Revision{
+			Number: r.Number,
+			Time:   r.Time,
+			Goal:   r.Goal.Copy(),
+		}
+	}
+
+	return list
+}
+
+// Rollback restores the goal of the given revision from the Pool's history,
+// pushing it to VMS in a single operation, with the same hook behaviour as
+// SetGoal. The restored goal is recorded in the history as a new revision.
+func (p *Pool) Rollback(revision int) error {
+
+	if p.mgr.closed {
+		return ErrManagerClosed
+	}
+
+	p.statusLock.Lock()
+	defer p.unlock()
+
+	for _, r := range p.history {
+		if r.Number == revision {
+			return p.replace(r.Goal.Copy(), nil)
+		}
+	}
+
+	return ErrRevisionNotFound
+}
@@ -0,0 +1,77 @@
+package deploy
+
+// Hooks is a set of optional callbacks invoked by a Pool as its instances move
+// through their lifecycle. Any of the callbacks may be left nil. Callbacks are
+// run synchronously after the Pool has released its internal locks, so they may
+// safely call back into the Pool, but a slow callback will delay the operation
+// that triggered it.
+type Hooks struct {
+	// OnSpawn is called after a new instance has been added to the Pool's
+	// goal and pushed to VMS.
+	OnSpawn func(id string, args *SpawnArgs)
+
+	// OnReady is called the first time an Update finds that an instance is
+	// Running.
+	OnReady func(id string, status *InstanceStatus)
+
+	// OnDestroy is called after an instance has been removed from the Pool's
+	// goal and the change has been pushed to VMS.
+	OnDestroy func(id string)
+
+	// OnUpdateError is called whenever Update fails.
+	OnUpdateError func(err error)
+}
+
+// SetHooks replaces the Pool's lifecycle hooks. A nil value removes all hooks.
+func (p *Pool) SetHooks(hooks *Hooks) {
+	p.statusLock.Lock()
+	defer p.statusLock.Unlock()
+	if hooks == nil {
+		hooks = new(Hooks)
+	}
+	p.hooks = hooks
+}
+
+// later queues fn to be run once the statusLock is released by unlock. The
+// caller must hold the statusLock.
+func (p *Pool) later(fn func()) {
+	p.deferred = append(p.deferred, fn)
+}
+
+// unlock releases the statusLock and then runs any functions queued by later
+// while it was held.
+func (p *Pool) unlock() {
+	list := p.deferred
+	p.deferred = nil
+	p.statusLock.Unlock()
+
+	for _, fn := range list {
+		fn()
+	}
+}
+
+// checkReady fires the OnReady hook for instances that have become Running
+// since the last Update. The caller must hold the statusLock.
+func (p *Pool) checkReady() {
+
+	for id := range p.ready {
+		if _, ok := p.goal.lookup(id); !ok {
+			delete(p.ready, id)
+		}
+	}
+
+	for id, status := range p.state.children {
+		if _, ok := p.goal.lookup(id); !ok {
+			continue
+		}
+		if p.ready[id] || p.states[id] != Running {
+			continue
+		}
+		p.ready[id] = true
+
+		if fn := p.hooks.OnReady; fn != nil {
+			id, status := id, status
+			p.later(func() { fn(id, status) })
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package deploy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrInstanceExists is returned whenever an instance ID requested for a new
+// instance is already in use within the Pool.
+var ErrInstanceExists = errors.New("instance id already exists in pool")
+
+// ErrInvalidInstanceID is returned whenever a caller-provided instance ID is
+// not a valid name.
+var ErrInvalidInstanceID = errors.New("invalid instance id")
+
+// idLength is the number of random bytes used to generate an instance ID.
+const idLength = 8
+
+// idAttempts is the number of times generateID will try to find an unused ID
+// before giving up.
+const idAttempts = 8
+
+var idPattern = regexp.MustCompile("^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$")
+
+// ValidInstanceID reports whether id may be used as a caller-provided instance
+// ID. Valid IDs are between 1 and 63 characters long, consist of lower case
+// letters, digits, and hyphens, and do not begin or end with a hyphen.
+func ValidInstanceID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// validGroup reports whether every element of a slash-separated subtree path
+// is a valid ID.
+func validGroup(group string) bool {
+	if group == "" {
+		return true
+	}
+	for _, id := range strings.Split(group, "/") {
+		if !ValidInstanceID(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// generateID returns a random instance path within the named subtree of the
+// goal that is not already in use.
+func generateID(g *DeploymentGoal, group string) (string, error) {
+
+	src := make([]byte, idLength)
+	for i := 0; i < idAttempts; i++ {
+		_, err := rand.Read(src)
+		if err != nil {
+			return "", err
+		}
+
+		id := joinPath(group, hex.EncodeToString(src))
+		if !g.exists(id) {
+			return id, nil
+		}
+	}
+
+	return "", errors.New("failed to generate a unique instance id")
+}
+
+// newID returns the path to use for a new instance within the named subtree of
+// the goal: either the validated name requested by the caller, or a random ID
+// if name is empty.
+func newID(g *DeploymentGoal, group, name string) (string, error) {
+
+	if !validGroup(group) {
+		return "", ErrInvalidInstanceID
+	}
+
+	if !g.groupAvailable(group) {
+		return "", ErrInstanceExists
+	}
+
+	if name == "" {
+		return generateID(g, group)
+	}
+
+	if !ValidInstanceID(name) {
+		return "", ErrInvalidInstanceID
+	}
+
+	id := joinPath(group, name)
+	if g.exists(id) {
+		return "", ErrInstanceExists
+	}
+
+	return id, nil
+}
+
+func joinPath(group, id string) string {
+	if group == "" {
+		return id
+	}
+	return group + "/" + id
+}
@@ -0,0 +1,210 @@
+package deploy
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotLeaseHolder is returned whenever a Pool that requires a lease attempts
+// to change its deployment without holding the lease.
+var ErrNotLeaseHolder = errors.New("pool lease not held")
+
+// Labels recorded in the metadata of a deployment to track its lease.
+const (
+	LeaseHolderLabel  = "lease-holder"
+	LeaseExpiresLabel = "lease-expires"
+)
+
+// OpenPool returns a Pool for an existing deployment in the named organization,
+// so that several Managers, typically replicas of the same controller, can
+// share it. The Pool's goal is reconstructed from the deployment's current
+// state, so its instances have no Labels and are ignored by anti-affinity
+// constraints. Unlike Pools created with NewPool, the deployment is not deleted
+// when the Manager is closed, although it is still deleted by the Pool's own
+// Close function.
+//
+// Pools opened this way require a lease before they will change the
+// deployment: until AcquireLease succeeds, every change fails with
+// ErrNotLeaseHolder and automatic restarts are suspended, leaving the Pool on
+// standby.
+func (m *Manager) OpenPool(org, name string) (*Pool, error) {
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+
+	state, err := m.backend.GetDeployment(org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := m.backend.GetMetadata(org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	p := m.newPool(org, name)
+	p.goal = goalFromState(state)
+	p.state = state
+	p.metadata = md
+	p.adopted = true
+	p.requireLease = true
+	p.annotateState()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+	m.pools[p.key()] = p
+	m.release(p, len(p.goal.instances()))
+
+	return p, nil
+}
+
+// goalFromState returns the goal stored with a state if VMS reported one, and
+// otherwise reconstructs a goal describing the instances in the state. A
+// reconstructed goal only has the Platform, App, and Version of each VM. Since
+// labels are not sent to VMS, neither has any Labels.
+func goalFromState(state *DeploymentState) *DeploymentGoal {
+	if state.goal != nil {
+		return state.goal.Copy()
+	}
+	g := NewDeploymentGoal()
+	for id, status := range state.children {
+		g.attachPath(id, &VM{
+			Platform: status.Platform,
+			App:      status.App,
+			Version:  status.Version,
+		})
+	}
+	return g
+}
+
+// RequireLease controls whether the Pool must hold its deployment's lease
+// before changing the deployment. It is enabled automatically for Pools opened
+// with OpenPool.
+func (p *Pool) RequireLease(require bool) {
+	p.statusLock.Lock()
+	defer p.statusLock.Unlock()
+	p.requireLease = require
+}
+
+// AcquireLease attempts to take, or renew, the lease on the Pool's deployment
+// for the given duration on behalf of the Pool's Manager. It returns false if
+// another Manager holds an unexpired lease. Leases are stored in the
+// deployment's metadata; after writing the lease the Pool reads it back to
+// detect a competing Manager that wrote at the same time, but this is not a
+// substitute for a proper distributed lock, and the ttl should comfortably
+// exceed the interval at which the lease is renewed.
+func (p *Pool) AcquireLease(ttl time.Duration) (bool, error) {
+
+	if p.mgr.closed {
+		return false, ErrManagerClosed
+	}
+
+	if p.readOnly {
+		return false, ErrReadOnly
+	}
+
+	owner := p.mgr.OwnerID()
+
+	p.statusLock.Lock()
+	defer p.unlock()
+
+	md, err := p.mgr.backend.GetMetadata(p.org, p.name)
+	if err != nil {
+		return false, err
+	}
+
+	holder := md.Labels[LeaseHolderLabel]
+	expires, _ := time.Parse(time.RFC3339Nano, md.Labels[LeaseExpiresLabel])
+	if holder != "" && holder != owner && time.Now().Before(expires) {
+		p.leaseExpiry = time.Time{}
+		return false, nil
+	}
+
+	expires = time.Now().Add(ttl)
+
+	md = md.Copy()
+	if md.Labels == nil {
+		md.Labels = make(map[string]string)
+	}
+	md.Labels[LeaseHolderLabel] = owner
+	md.Labels[LeaseExpiresLabel] = expires.UTC().Format(time.RFC3339Nano)
+
+	err = p.mgr.backend.UpdateMetadata(p.org, p.name, md)
+	if err != nil {
+		return false, err
+	}
+
+	check, err := p.mgr.backend.GetMetadata(p.org, p.name)
+	if err != nil {
+		return false, err
+	}
+
+	if check.Labels[LeaseHolderLabel] != owner {
+		p.leaseExpiry = time.Time{}
+		return false, nil
+	}
+
+	p.metadata = check
+	p.leaseExpiry = expires
+
+	return true, nil
+}
+
+// ReleaseLease gives up the lease on the Pool's deployment, if the Pool's
+// Manager holds it, so that a standby Manager can take over immediately.
+func (p *Pool) ReleaseLease() error {
+
+	if p.readOnly {
+		return ErrReadOnly
+	}
+
+	owner := p.mgr.OwnerID()
+
+	p.statusLock.Lock()
+	defer p.unlock()
+
+	p.leaseExpiry = time.Time{}
+
+	md, err := p.mgr.backend.GetMetadata(p.org, p.name)
+	if err != nil {
+		return err
+	}
+
+	if md.Labels[LeaseHolderLabel] != owner {
+		return nil
+	}
+
+	md = md.Copy()
+	delete(md.Labels, LeaseHolderLabel)
+	delete(md.Labels, LeaseExpiresLabel)
+
+	err = p.mgr.backend.UpdateMetadata(p.org, p.name, md)
+	if err != nil {
+		return err
+	}
+
+	p.metadata = md
+	return nil
+}
+
+// HoldsLease reports whether the Pool currently believes it holds an unexpired
+// lease on its deployment.
+func (p *Pool) HoldsLease() bool {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+	return p.holdsLease()
+}
+
+// active reports whether the Pool may change its deployment. The caller must
+// hold the statusLock.
+func (p *Pool) active() bool {
+	return !p.readOnly && (!p.requireLease || p.holdsLease())
+}
+
+func (p *Pool) holdsLease() bool {
+	return time.Now().Before(p.leaseExpiry)
+}
@@ -0,0 +1,168 @@
+package deploy
+
+import (
+	"fmt"
+)
+
+// InstanceState is the lifecycle state of an instance, computed by combining
+// the instance's membership in the Pool's goal with the last known state
+// reported by VMS.
+type InstanceState int
+
+// Instance lifecycle states.
+const (
+	// Pending instances are in the goal but have not been reported by VMS.
+	Pending InstanceState = iota
+
+	// Provisioning instances are in the goal and have been reported by VMS,
+	// but are not yet running.
+	Provisioning
+
+	// Running instances are in the goal and reported running by VMS.
+	Running
+
+	// Stopping instances have been removed from the goal but are still
+	// reported by VMS.
+	Stopping
+
+	// Terminated instances have been removed from the goal and are no longer
+	// reported by VMS.
+	Terminated
+
+	// Failed instances are in the goal but have been reported failed by VMS.
+	Failed
+)
+
+var instanceStateNames = map[InstanceState]string{
+	Pending:      "pending",
+	Provisioning: "provisioning",
+	Running:      "running",
+	Stopping:     "stopping",
+	Terminated:   "terminated",
+	Failed:       "failed",
+}
+
+// String returns the lower case name of the state.
+func (s InstanceState) String() string {
+	if name, ok := instanceStateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("InstanceState(%d)", int(s))
+}
+
+var instanceTransitions = map[InstanceState][]InstanceState{
+	Pending:      {Provisioning, Running, Failed, Stopping, Terminated},
+	Provisioning: {Pending, Running, Failed, Stopping, Terminated},
+	Running:      {Pending, Failed, Stopping, Terminated},
+	Stopping:     {Pending, Terminated},
+	Terminated:   {Pending},
+	Failed:       {Pending, Provisioning, Running, Stopping, Terminated},
+}
+
+// CanTransition reports whether an instance may legally move from state s to
+// state to. Moving back to Pending is always legal from states that involve a
+// goal change, since an instance can be restarted or its ID reused.
+func (s InstanceState) CanTransition(to InstanceState) bool {
+	if s == to {
+		return true
+	}
+	for _, x := range instanceTransitions[s] {
+		if x == to {
+			return true
+		}
+	}
+	return false
+}
+
+// vmsStates maps the provisioning states reported by VMS onto InstanceStates
+// for instances that are still in the goal.
+var vmsStates = map[string]InstanceState{
+	"pending":      Provisioning,
+	"provisioning": Provisioning,
+	"booting":      Provisioning,
+	"running":      Running,
+	"failed":       Failed,
+	"error":        Failed,
+}
+
+// computeState derives the InstanceState of an instance from its presence in
+// the goal and its last known status, which may be nil.
+func computeState(inGoal bool, status *InstanceStatus) InstanceState {
+
+	if !inGoal {
+		if status == nil {
+			return Terminated
+		}
+		return Stopping
+	}
+
+	if status == nil {
+		return Pending
+	}
+
+	if s, ok := vmsStates[status.State]; ok {
+		return s
+	}
+
+	// Older VMS releases do not report a provisioning state.
+	if status.State == "" && status.IP != "" {
+		return Running
+	}
+
+	return Provisioning
+}
+
+// State returns the current InstanceState of the instance named by id, based on
+// the Pool's goal and its last known state. Like Status, it does not poll VMS.
+// Instances that have been destroyed are reported as Terminated until the next
+// Update, after which they are no longer part of the Pool.
+func (p *Pool) State(id string) (InstanceState, error) {
+
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+
+	_, inGoal := p.goal.lookup(id)
+	status, inState := p.state.children[id]
+	if !inGoal && !inState {
+		if _, ok := p.states[id]; ok {
+			return Terminated, nil
+		}
+		return 0, ErrInstanceNotInPool
+	}
+
+	return computeState(inGoal, status), nil
+}
+
+// trackStates recomputes the state of every instance known to the Pool after an
+// Update, emitting an event for any transition that is not legal. The caller
+// must hold the statusLock.
+func (p *Pool) trackStates() {
+
+	next := make(map[string]InstanceState)
+
+	goal := p.goal.instances()
+	for id := range goal {
+		next[id] = computeState(true, p.state.children[id])
+	}
+
+	for id, status := range p.state.children {
+		if _, ok := goal[id]; !ok {
+			next[id] = computeState(false, status)
+		}
+	}
+
+	for id, prev := range p.states {
+		if _, ok := next[id]; !ok && prev != Terminated {
+			next[id] = Terminated
+		}
+	}
+
+	for id, s := range next {
+		prev, ok := p.states[id]
+		if ok && !prev.CanTransition(s) {
+			p.emit(EventIllegalTransition, id, fmt.Errorf("illegal transition from %s to %s", prev, s))
+		}
+	}
+
+	p.states = next
+}
@@ -1,8 +1,7 @@
 package deploy
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
@@ -20,46 +19,57 @@ var ErrManagerClosed = errors.New("manager closed")
 // given instance ID within the Pool being searched.
 var ErrInstanceNotInPool = errors.New("instance id not found in pool")
 
+// ErrNoClient is returned whenever a Manager created without an api.Client is
+// asked to do something its Backend does not cover, such as looking up the
+// organization's platforms.
+var ErrNoClient = errors.New("manager has no api client")
+
 // Manager simplifies and automates some common deployment operations. It also
 // cleans up after itself, guaranteeing that everything created by the Manager
 // will be deleted from VMS if the Manager's Close function is called. The zero
 // value for a Manager is not a usable Manager.
 type Manager struct {
-	closed bool
-	lock   sync.Mutex
-	client *api.Client
-	pools  map[string]*Pool
+	closed  bool
+	lock    sync.Mutex
+	client  *api.Client
+	backend Backend
+	pools   map[string]*Pool
+	owner   string
+	errors  chan *ErrorEvent
+
+	quotaLock    sync.Mutex
+	maxInstances int
+	counts       map[*Pool]int
 }
 
 // NewManager returns a usable manager created from an authenticated api.Client
 // object.
 func NewManager(client *api.Client) (*Manager, error) {
+	return NewManagerWithBackend(client, NewHTTPBackend(client))
+}
+
+// NewManagerWithBackend is like NewManager, but performs deployment operations
+// through the given Backend rather than directly against VMS. The client is
+// still used by operations the Backend does not cover, and may be nil if
+// those operations are not needed. Without a client, they return ErrNoClient:
+// these include Watch, goal validation, and Spawn without an explicit Platform
+// or with Constraints.
+func NewManagerWithBackend(client *api.Client, backend Backend) (*Manager, error) {
 	m := new(Manager)
 	m.client = client
+	m.backend = backend
 	m.pools = make(map[string]*Pool)
+	m.counts = make(map[*Pool]int)
+	m.owner = newOwnerID()
+	m.errors = make(chan *ErrorEvent, eventBufferSize)
 	return m, nil
 }
 
 // Close prevents the Manager from performing any more operations, and cleans up
-// all existing instances created by it.
+// all existing instances created by it. Pools opened with OpenPool or
+// ObservePool are left running. Use CloseWithProgress to monitor a lengthy cleanup.
 func (m *Manager) Close() error {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-	m.closed = true
-
-	list := make([]*Pool, 0)
-	for _, v := range m.pools {
-		list = append(list, v)
-	}
-
-	for _, pool := range list {
-		err := pool.Close()
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return m.CloseWithProgress(nil)
 }
 
 // Pool is a custom deployment of manually managed instances.
@@ -70,6 +80,30 @@ type Pool struct {
 	name       string
 	goal       *DeploymentGoal
 	state      *DeploymentState
+	metadata   *DeploymentMetadata
+	events     chan *Event
+	errors     chan *ErrorEvent
+
+	probe         Probe
+	restartPolicy *RestartPolicy
+	restarts      map[string]*restartRecord
+	maxInstances  int // guarded by the Manager's quotaLock
+	validation    bool
+
+	history  []*Revision
+	revision int
+
+	lastUpdated time.Time
+	states      map[string]InstanceState
+	adopted     bool
+	readOnly    bool
+
+	requireLease bool
+	leaseExpiry  time.Time
+
+	hooks    *Hooks
+	ready    map[string]bool
+	deferred []func()
 }
 
 // NewPool creates a new custom deployment of manually managed instances for the
@@ -80,23 +114,28 @@ func (m *Manager) NewPool(org, name string) (*Pool, error) {
 		return nil, ErrManagerClosed
 	}
 
-	p := new(Pool)
-	p.mgr = m
-	p.org = org
-	p.name = name
-	p.goal = new(DeploymentGoal)
-	p.goal.children = make(map[string]*VM)
-	p.state = new(DeploymentState)
-	p.state.children = make(map[string]*InstanceStatus)
+	p := m.newPool(org, name)
 
 	m.lock.Lock()
 	defer m.lock.Unlock()
 	if m.closed {
-		return nil, errors.New("closed")
+		return nil, ErrManagerClosed
 	}
 	p.mgr.pools[p.key()] = p
 
-	err := CreateDeployment(p.mgr.client, org, name)
+	p.metadata = &DeploymentMetadata{
+		Labels: map[string]string{
+			OwnerLabel:   m.owner,
+			CreatedLabel: time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+
+	err := p.mgr.backend.CreateDeployment(org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.mgr.backend.UpdateMetadata(org, name, p.metadata)
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +143,26 @@ func (m *Manager) NewPool(org, name string) (*Pool, error) {
 	return p, nil
 }
 
+// newPool returns an empty Pool for the named deployment, without creating or
+// registering anything.
+func (m *Manager) newPool(org, name string) *Pool {
+	p := new(Pool)
+	p.mgr = m
+	p.org = org
+	p.name = name
+	p.goal = NewDeploymentGoal()
+	p.state = new(DeploymentState)
+	p.state.children = make(map[string]*InstanceStatus)
+	p.metadata = new(DeploymentMetadata)
+	p.events = make(chan *Event, eventBufferSize)
+	p.errors = make(chan *ErrorEvent, eventBufferSize)
+	p.restarts = make(map[string]*restartRecord)
+	p.hooks = new(Hooks)
+	p.ready = make(map[string]bool)
+	p.states = make(map[string]InstanceState)
+	return p
+}
+
 func (p *Pool) key() string {
 	return fmt.Sprintf("%s::%s", p.org, p.name)
 }
@@ -112,10 +171,13 @@ func (p *Pool) key() string {
 // Instances that are scheduled to be created will be included in the list even
 // if they have not yet been provisioned. Conversely, instances that are
 // scheduled to be destroyed will not be included in the list even if they are
-// still running.
+// still running. Instances within nested subtrees are identified by their
+// slash-separated path, such as "frontend/3fa1c0de9b2e4d77".
 func (p *Pool) Instances() []string {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
 	list := make([]string, 0)
-	for k := range p.goal.children {
+	for k := range p.goal.instances() {
 		list = append(list, k)
 	}
 	sort.Strings(list)
@@ -127,14 +189,62 @@ func (p *Pool) Instances() []string {
 // path to an application within an organization's online repository. Version
 // cannot be left empty and must be a valid ID string for the App, *a tag is not
 // valid*. Use the apps.ResolveVersionToID function to handle those use-cases.
+// Name is optional, and if provided is used as the new instance's ID instead of
+// a randomly generated one. It must be unique within the Pool and satisfy
+// ValidInstanceID. Group is optional, and if provided names the nested subtree
+// of the deployment the instance should be attached to as a slash-separated
+// path, such as "frontend" or "region-a/workers". Missing subtrees are created
+// automatically.
+//
+// Labels are arbitrary key-value pairs recorded against the instance by the
+// Pool. If Constraints is provided, the Pool chooses the instance's platform
+// from the organization's platforms according to them, treating Platform, if it
+// is not empty, as the most preferred choice. Otherwise, if Platform is empty,
+// the organization's default platform is used.
+//
+// Fallback is an ordered list of platforms to try in turn if provisioning the
+// instance on the chosen platform is rejected by VMS, or fails validation
+// because of its platform or network. Other errors, such as ErrQuotaExceeded or
+// a failure to reach VMS, are returned without trying the fallback platforms.
+// The platform that actually hosts the instance is reported by InstanceStatus.
+//
+// Customization is an optional VMS customization document for the instance,
+// passed through to VMS unchanged. See VM.
+//
+// Ports lists the instance's ports to expose, and Network optionally selects
+// the platform network to attach it to. The external ports actually assigned
+// are reported by InstanceStatus.
 type SpawnArgs struct {
-	Platform string
-	App      string
-	Version  string
+	Platform    string
+	App         string
+	Version     string
+	Name        string
+	Group       string
+	Labels      map[string]string
+	Constraints *Constraints
+	Fallback    []string
+
+	Customization json.RawMessage
+	Ports         []PortMapping
+	Network       string
 }
 
-// Spawn creates a new instance from the provided SpawnArgs and retrns a new
-// instance ID generated for it.
+// vm returns the VM described by the SpawnArgs, hosted on the given platform.
+func (args *SpawnArgs) vm(platform string) *VM {
+	return &VM{
+		Platform:      platform,
+		App:           args.App,
+		Version:       args.Version,
+		Labels:        args.Labels,
+		Customization: args.Customization,
+		Ports:         args.Ports,
+		Network:       args.Network,
+	}
+}
+
+// Spawn creates a new instance from the provided SpawnArgs and returns its
+// instance ID, which is either the requested Name or a new randomly generated
+// ID.
 func (p *Pool) Spawn(args *SpawnArgs) (string, error) {
 
 	if p.mgr.closed {
@@ -142,30 +252,41 @@ func (p *Pool) Spawn(args *SpawnArgs) (string, error) {
 	}
 
 	p.statusLock.Lock()
-	defer p.statusLock.Unlock()
+	defer p.unlock()
 
-	src := make([]byte, 4)
-	rand.Read(src)
-	id := hex.EncodeToString(src)
+	id, err := newID(p.goal, args.Group, args.Name)
+	if err != nil {
+		return "", err
+	}
 
-	g := p.goal.Copy()
-	g.Attach(id, &VM{
-		Platform: args.Platform,
-		App:      args.App,
-		Version:  args.Version,
-	})
+	platform, err := p.place(p.goal, args)
+	if err != nil {
+		return "", err
+	}
 
-	err := g.Push(p.mgr.client, p.org, p.name)
+	for _, platform := range failover(platform, args.Fallback) {
+		g := p.goal.Copy()
+		g.attachPath(id, args.vm(platform))
+
+		err = p.push(g)
+		if err == nil || !platformError(err) {
+			break
+		}
+		api.Log.Debug("Spawn on platform failed", "platform", platform, "err", err)
+	}
 	if err != nil {
 		return "", err
 	}
 
-	p.goal = g
+	if fn := p.hooks.OnSpawn; fn != nil {
+		p.later(func() { fn(id, args) })
+	}
 
 	return id, nil
 }
 
-// Destroy terminates the instance named by the given ID.
+// Destroy terminates the instance named by the given ID. The instance is
+// removed from the Pool only if the change is accepted by VMS.
 func (p *Pool) Destroy(id string) error {
 
 	if p.mgr.closed {
@@ -173,45 +294,127 @@ func (p *Pool) Destroy(id string) error {
 	}
 
 	p.statusLock.Lock()
-	defer p.statusLock.Unlock()
+	defer p.unlock()
+
+	if _, ok := p.goal.lookup(id); !ok {
+		return ErrInstanceNotInPool
+	}
+
+	g := p.goal.Copy()
+	g.detachPath(id)
 
-	p.goal.Detach(id)
+	err := p.push(g)
+	if err != nil {
+		return err
+	}
 
-	p.goal.Push(p.mgr.client, p.org, p.name)
+	if fn := p.hooks.OnDestroy; fn != nil {
+		p.later(func() { fn(id) })
+	}
 
 	return nil
 }
 
+// DestroyAsync is like Destroy, but returns immediately. The returned channel
+// receives the result of the operation, which is nil on success, and is then
+// closed. Failures are also reported through the Errors channels.
+func (p *Pool) DestroyAsync(id string) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		err := p.Destroy(id)
+		if err != nil {
+			p.fail("destroy", id, err)
+		}
+		ch <- err
+		close(ch)
+	}()
+	return ch
+}
+
 // InstanceStatus contains information returned about an instance as received
-// from VMS.
+// from VMS. If VMS does not report the instance's Platform, it is filled in from
+// the Pool's goal.
+//
+// State is the provisioning state reported by VMS, such as "pending",
+// "booting", "running", or "failed". If the instance has failed, Reason
+// contains the failure reason given by VMS.
 type InstanceStatus struct {
-	Deployer string   `json:"deployer"`
-	App      string   `json:"app"`
-	Version  string   `json:"version"`
-	Hostname string   `json:"hostname"`
-	IP       string   `json:"ip"`
-	URLs     []string `json:"urls"`
+	Platform string       `json:"platform"`
+	Deployer string       `json:"deployer"`
+	App      string       `json:"app"`
+	Version  string       `json:"version"`
+	Hostname string       `json:"hostname"`
+	IP       string       `json:"ip"`
+	URLs     []string     `json:"urls"`
+	Ports    []PortStatus `json:"ports"`
+	State    string       `json:"state"`
+	Created  time.Time    `json:"created"`
+	Reason   string       `json:"reason"`
+
+	// LastUpdated is the time this information was received from VMS.
+	LastUpdated time.Time `json:"-"`
+}
+
+// Port returns the external port status for one of the instance's ports. An
+// empty protocol matches "tcp".
+func (s *InstanceStatus) Port(port int, protocol string) (*PortStatus, bool) {
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	for i := range s.Ports {
+		x := &s.Ports[i]
+		p := x.Protocol
+		if p == "" {
+			p = "tcp"
+		}
+		if x.Port == port && p == protocol {
+			return x, true
+		}
+	}
+	return nil, false
 }
 
 // Status returns the last known InstanceStatus for the instance named by ID.
 // This function does not poll VMS to update the InstanceStatus information. Use
 // the Update function periodically to get the latest information.
 func (p *Pool) Status(id string) (*InstanceStatus, error) {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
 	v, ok := p.state.children[id]
 	if !ok {
-		_, ok = p.goal.children[id]
+		vm, ok := p.goal.lookup(id)
 		if !ok {
 			return nil, ErrInstanceNotInPool
 		}
-		return &InstanceStatus{}, nil
+		return &InstanceStatus{Platform: vm.Platform}, nil
 	}
 	return v, nil
 }
 
-// Close destroys the VMS deployment managed by the pool.
+// URLs returns the last known deployment-level URLs for the Pool, which load
+// balance across all of its instances. Like Status, this does not poll VMS; the
+// list is refreshed by Update.
+func (p *Pool) URLs() []string {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+	return p.state.URLs()
+}
+
+// Close destroys the VMS deployment managed by the pool. If VMS does not delete
+// the deployment within 60 seconds, Close escalates to force-deleting each of
+// its instances and then the deployment itself; if anything still cannot be
+// removed, it returns a *CleanupError describing what was left behind. Closing
+// a Pool opened with ObservePool leaves its deployment untouched.
 func (p *Pool) Close() error {
 	p.statusLock.Lock()
 	defer p.statusLock.Unlock()
+
+	if p.readOnly {
+		p.goal = nil
+		p.state = nil
+		return nil
+	}
+
 	timeout := time.After(time.Second * 60)
 
 	ch := make(chan error)
@@ -219,7 +422,7 @@ func (p *Pool) Close() error {
 		defer func() {
 			recover()
 		}()
-		ch <- DeleteDeployment(p.mgr.client, p.org, p.name)
+		ch <- p.mgr.backend.DeleteDeployment(p.org, p.name)
 	}()
 
 	select {
@@ -229,15 +432,85 @@ func (p *Pool) Close() error {
 		}
 	case <-timeout:
 		close(ch)
-		return errors.New("cleanup timed out")
+		err := p.forceDelete(&ErrTimeout{Op: "delete deployment"})
+		if err != nil {
+			return err
+		}
 	}
 
 	p.goal = nil
 	p.state = nil
 	delete(p.mgr.pools, p.key())
+	p.mgr.release(p, -1)
 	return nil
 }
 
+// applyState replaces the Pool's last known state, and then reacts to any
+// changes. The caller must hold the statusLock.
+func (p *Pool) applyState(state *DeploymentState) {
+	p.state = state
+	p.annotateState()
+	p.trackStates()
+	p.checkReady()
+	p.checkHealth()
+}
+
+// annotateState records when the latest state was received, and fills in any
+// information missing from it that can be inferred from the goal. The caller
+// must hold the statusLock.
+func (p *Pool) annotateState() {
+	now := time.Now()
+	p.lastUpdated = now
+	for id, status := range p.state.children {
+		status.LastUpdated = now
+		if status.Platform != "" {
+			continue
+		}
+		if vm, ok := p.goal.lookup(id); ok {
+			status.Platform = vm.Platform
+		}
+	}
+}
+
+// failover returns the ordered, de-duplicated list of platforms to attempt for
+// a new instance.
+func failover(platform string, fallback []string) []string {
+	list := []string{platform}
+	seen := map[string]bool{platform: true}
+	for _, x := range fallback {
+		if !seen[x] {
+			seen[x] = true
+			list = append(list, x)
+		}
+	}
+	return list
+}
+
+// platformError reports whether err, returned by pushing a goal with a new
+// instance, could be specific to the platform the instance was placed on, so
+// that Spawn should try the next fallback platform. That is the case if VMS
+// rejected the goal, or if it failed validation only because of the platform
+// or network chosen.
+func platformError(err error) bool {
+
+	var rejected *ErrPushRejected
+	if errors.As(err, &rejected) {
+		return true
+	}
+
+	var report *ValidationReport
+	if !errors.As(err, &report) || len(report.Problems) == 0 {
+		return false
+	}
+	for _, x := range report.Problems {
+		if x.Field != "platform" && x.Field != "network" {
+			return false
+		}
+	}
+
+	return true
+}
+
 type tuple struct {
 	pl  interface{}
 	err error
@@ -245,7 +518,8 @@ type tuple struct {
 
 // Update polls VMS for the latest state information about the pool's VMS
 // deployment. This should be called periodically, or whenever the latest
-// information is required. It updates all VMs within the pool at once.
+// information is required. It updates all VMs within the pool at once, and
+// then applies the Pool's health probe and restart policy, if any.
 func (p *Pool) Update() error {
 
 	if p.mgr.closed {
@@ -253,7 +527,21 @@ func (p *Pool) Update() error {
 	}
 
 	p.statusLock.Lock()
-	defer p.statusLock.Unlock()
+	defer p.unlock()
+
+	err := p.update()
+	if err != nil {
+		if fn := p.hooks.OnUpdateError; fn != nil {
+			p.later(func() { fn(err) })
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *Pool) update() error {
+
 	timeout := time.After(time.Second * 60)
 
 	ch := make(chan *tuple)
@@ -261,9 +549,10 @@ func (p *Pool) Update() error {
 		defer func() {
 			recover()
 		}()
-		state, err := GetDeployment(p.mgr.client, p.org, p.name)
+		state, err := p.mgr.backend.GetDeployment(p.org, p.name)
 		if err != nil {
 			ch <- &tuple{err: err}
+			return
 		}
 		ch <- &tuple{pl: state}
 	}()
@@ -273,10 +562,10 @@ func (p *Pool) Update() error {
 		if x.err != nil {
 			return x.err
 		}
-		p.state = x.pl.(*DeploymentState)
+		p.applyState(x.pl.(*DeploymentState))
 	case <-timeout:
 		close(ch)
-		return errors.New("cleanup timed out")
+		return &ErrTimeout{Op: "get deployment"}
 	}
 
 	return nil
@@ -0,0 +1,89 @@
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Node is an element of a deployment tree. VMs and subtrees (DeploymentGoals)
+// are built in; other kinds of node supported by VMS, such as scalers or
+// routers, can be used by implementing Node and registering the type with
+// RegisterNodeType. A Node's MarshalJSON must include a "type" field matching
+// its NodeType. Nodes are treated as immutable once attached to a goal, and are
+// shared between copies of it.
+type Node interface {
+	json.Marshaler
+	NodeType() string
+}
+
+var (
+	nodeTypesLock sync.RWMutex
+	nodeTypes     = make(map[string]func() Node)
+)
+
+func init() {
+	RegisterNodeType("vm", func() Node { return new(VM) })
+	RegisterNodeType("subtree", func() Node { return new(DeploymentGoal) })
+}
+
+// RegisterNodeType makes a custom node type known to the deploy package, so
+// that nodes of that type can be decoded when a goal is unmarshalled. The
+// factory must return a new, empty Node, which is populated with
+// json.Unmarshal. Registering a type again replaces the previous factory.
+func RegisterNodeType(typ string, factory func() Node) {
+	nodeTypesLock.Lock()
+	defer nodeTypesLock.Unlock()
+	nodeTypes[typ] = factory
+}
+
+// RawNode holds a node of a type that has not been registered with
+// RegisterNodeType. It preserves the node exactly as it was received, so goals
+// containing unknown node types can still be modified and pushed back to VMS.
+type RawNode struct {
+	Type string
+	Data json.RawMessage
+}
+
+// NodeType returns the node's type as it was reported by VMS.
+func (x *RawNode) NodeType() string {
+	return x.Type
+}
+
+// MarshalJSON returns the node exactly as it was received.
+func (x *RawNode) MarshalJSON() ([]byte, error) {
+	return x.Data, nil
+}
+
+func unmarshalNode(data json.RawMessage) (Node, error) {
+
+	x := new(struct {
+		Type string `json:"type"`
+	})
+	err := json.Unmarshal(data, x)
+	if err != nil {
+		return nil, err
+	}
+
+	if x.Type == "" {
+		return nil, fmt.Errorf("node is missing a type")
+	}
+
+	nodeTypesLock.RLock()
+	factory, ok := nodeTypes[x.Type]
+	nodeTypesLock.RUnlock()
+
+	if !ok {
+		raw := make(json.RawMessage, len(data))
+		copy(raw, data)
+		return &RawNode{Type: x.Type, Data: raw}, nil
+	}
+
+	node := factory()
+	err = json.Unmarshal(data, node)
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
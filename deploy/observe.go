@@ -0,0 +1,48 @@
+package deploy
+
+import (
+	"errors"
+)
+
+// ErrReadOnly is returned whenever an operation that would change a deployment
+// is attempted through a Pool opened with ObservePool.
+var ErrReadOnly = errors.New("pool is read-only")
+
+// ObservePool returns a read-only Pool for an existing deployment. The Pool may
+// be updated, watched, and queried for status like any other, but every
+// operation that would change the deployment fails with ErrReadOnly, health
+// probes and automatic restarts are disabled, and neither the Pool's Close
+// function nor the Manager's touches the deployment. Observed Pools are not
+// registered with the Manager, so they may observe deployments the Manager
+// also manages, and do not count towards its instance quota.
+func (m *Manager) ObservePool(org, name string) (*Pool, error) {
+
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+
+	state, err := m.backend.GetDeployment(org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := m.backend.GetMetadata(org, name)
+	if err != nil {
+		return nil, err
+	}
+
+	p := m.newPool(org, name)
+	p.goal = goalFromState(state)
+	p.state = state
+	p.metadata = md
+	p.adopted = true
+	p.readOnly = true
+	p.annotateState()
+
+	return p, nil
+}
+
+// ReadOnly reports whether the Pool was opened with ObservePool.
+func (p *Pool) ReadOnly() bool {
+	return p.readOnly
+}
@@ -0,0 +1,94 @@
+package deploy
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// ErrNoOwnerID is returned by CleanupOrphans if it is not given an owner ID,
+// since every deployment not created by a Manager would otherwise match.
+var ErrNoOwnerID = errors.New("no owner id")
+
+// Labels recorded in the metadata of every deployment created by a Manager.
+const (
+	OwnerLabel   = "owner"
+	CreatedLabel = "created"
+)
+
+func newOwnerID() string {
+	src := make([]byte, idLength)
+	_, err := rand.Read(src)
+	if err != nil {
+		// Fall back on something that is at least unlikely to collide.
+		return hex.EncodeToString([]byte(time.Now().Format(time.RFC3339Nano)))
+	}
+	return hex.EncodeToString(src)
+}
+
+// OwnerID returns the owner ID the Manager records against every deployment it
+// creates.
+func (m *Manager) OwnerID() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.owner
+}
+
+// SetOwnerID replaces the randomly generated owner ID of the Manager. Using a
+// stable owner ID lets a restarted process find and clean up the deployments
+// leaked by its predecessor with CleanupOrphans. It only affects Pools created
+// afterwards.
+func (m *Manager) SetOwnerID(id string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.owner = id
+}
+
+// CleanupOrphans deletes every deployment in the named organization that was
+// created by a Manager with the given owner ID more than olderThan ago, and
+// returns the names of the deployments it deleted. It should be called before
+// a Manager with the same owner ID creates any Pools, since those would be
+// indistinguishable from orphans. Deployments without a valid CreatedLabel
+// are never deleted.
+func CleanupOrphans(client *api.Client, org, ownerID string, olderThan time.Duration) ([]string, error) {
+	return CleanupOrphansWithBackend(NewHTTPBackend(client), org, ownerID, olderThan)
+}
+
+// CleanupOrphansWithBackend is like CleanupOrphans, but lists and deletes
+// deployments through the given Backend.
+func CleanupOrphansWithBackend(backend Backend, org, ownerID string, olderThan time.Duration) ([]string, error) {
+
+	if ownerID == "" {
+		return nil, ErrNoOwnerID
+	}
+
+	list, err := backend.ListDeployments(org)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make([]string, 0)
+	for _, x := range list {
+
+		if x.Metadata.Labels[OwnerLabel] != ownerID {
+			continue
+		}
+
+		created, err := time.Parse(time.RFC3339, x.Metadata.Labels[CreatedLabel])
+		if err != nil || time.Since(created) < olderThan {
+			continue
+		}
+
+		err = backend.DeleteDeployment(org, x.Name)
+		if err != nil {
+			return deleted, err
+		}
+
+		deleted = append(deleted, x.Name)
+	}
+
+	return deleted, nil
+}
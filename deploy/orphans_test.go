@@ -0,0 +1,60 @@
+package deploy_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sisatech/api/deploy"
+	"github.com/sisatech/api/deploy/deploytest"
+)
+
+func TestCleanupOrphans(t *testing.T) {
+
+	b := deploytest.NewBackend()
+
+	seed := func(name string, labels map[string]string) {
+		err := b.CreateDeployment(testOrg, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if labels == nil {
+			return
+		}
+		err = b.UpdateMetadata(testOrg, name, &deploy.DeploymentMetadata{Labels: labels})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	recent := time.Now().UTC().Format(time.RFC3339)
+
+	seed("unlabeled", nil)
+	seed("foreign", map[string]string{deploy.OwnerLabel: "someone-else", deploy.CreatedLabel: old})
+	seed("no-created", map[string]string{deploy.OwnerLabel: "me"})
+	seed("bad-created", map[string]string{deploy.OwnerLabel: "me", deploy.CreatedLabel: "yesterday"})
+	seed("recent", map[string]string{deploy.OwnerLabel: "me", deploy.CreatedLabel: recent})
+	seed("orphan", map[string]string{deploy.OwnerLabel: "me", deploy.CreatedLabel: old})
+
+	_, err := deploy.CleanupOrphansWithBackend(b, testOrg, "", time.Minute)
+	if err != deploy.ErrNoOwnerID {
+		t.Fatalf("expected ErrNoOwnerID, got %v", err)
+	}
+	if n := len(b.Deployments(testOrg)); n != 6 {
+		t.Fatalf("cleanup without an owner ID deleted deployments: %d left", n)
+	}
+
+	deleted, err := deploy.CleanupOrphansWithBackend(b, testOrg, "me", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"orphan"}) {
+		t.Fatalf("expected only 'orphan' to be deleted, got %v", deleted)
+	}
+
+	expect := []string{"bad-created", "foreign", "no-created", "recent", "unlabeled"}
+	if got := b.Deployments(testOrg); !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expected %v to remain, got %v", expect, got)
+	}
+}
@@ -0,0 +1,138 @@
+package deploy
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/sisatech/api/platforms"
+)
+
+// ErrNoPlacement is returned whenever no platform satisfies the placement
+// constraints of a new instance.
+var ErrNoPlacement = errors.New("no platform satisfies placement constraints")
+
+// Constraints influence which of the organization's platforms a Pool chooses
+// when spawning an instance.
+//
+// Platforms is an ordered list of preferred platform names; the first one that
+// satisfies every other constraint is chosen. If none of them do, or the list
+// is empty, the Pool chooses whichever acceptable platform currently hosts the
+// fewest of its instances.
+//
+// AntiAffinity is a list of label keys. The new instance will not be placed on
+// a platform that already hosts an instance from the Pool whose label has the
+// same value for any of those keys as the new instance's label. Labels are not
+// stored in VMS, so only instances this process spawned into the Pool are
+// considered; instances adopted with OpenPool or ObservePool have no labels.
+//
+// Region is a hint: if any of the organization's platforms report being in the
+// named region, only they are considered.
+//
+// Requirements, unlike Region, are strict: only platforms that satisfy them
+// are considered at all. See platforms.Matching.
+type Constraints struct {
+	Platforms    []string
+	AntiAffinity []string
+	Region       string
+	Requirements *platforms.Requirements
+}
+
+// place chooses a platform for a new instance spawned from args, to be added to
+// the goal g.
+func (p *Pool) place(g *DeploymentGoal, args *SpawnArgs) (string, error) {
+
+	c := args.Constraints
+	if c == nil && args.Platform != "" {
+		return args.Platform, nil
+	}
+
+	// Every other choice needs the organization's platforms, which the
+	// Backend does not provide.
+	if p.mgr.client == nil {
+		return "", ErrNoClient
+	}
+
+	if c == nil {
+		return platforms.Default(p.mgr.client, p.org)
+	}
+
+	var list []*platforms.Platform
+	var err error
+	if c.Requirements != nil {
+		list, err = platforms.Matching(p.mgr.client, p.org, c.Requirements)
+	} else {
+		list, err = platforms.List(p.mgr.client, p.org)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if c.Region != "" {
+		regional := make([]*platforms.Platform, 0)
+		for _, x := range list {
+			if x.Region == c.Region {
+				regional = append(regional, x)
+			}
+		}
+		if len(regional) > 0 {
+			list = regional
+		}
+	}
+
+	instances := g.instances()
+
+	excluded := make(map[string]bool)
+	for _, key := range c.AntiAffinity {
+		val, ok := args.Labels[key]
+		if !ok {
+			continue
+		}
+		for _, vm := range instances {
+			if v, ok := vm.Labels[key]; ok && v == val {
+				excluded[vm.Platform] = true
+			}
+		}
+	}
+
+	candidates := make(map[string]bool)
+	for _, x := range list {
+		if !excluded[x.Name] {
+			candidates[x.Name] = true
+		}
+	}
+
+	preferred := c.Platforms
+	if args.Platform != "" {
+		preferred = append([]string{args.Platform}, preferred...)
+	}
+
+	for _, name := range preferred {
+		if candidates[name] {
+			return name, nil
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", ErrNoPlacement
+	}
+
+	load := make(map[string]int)
+	for _, vm := range instances {
+		load[vm.Platform]++
+	}
+
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best := names[0]
+	for _, name := range names[1:] {
+		if load[name] < load[best] {
+			best = name
+		}
+	}
+
+	return best, nil
+}
@@ -0,0 +1,584 @@
+package deploy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sisatech/api"
+	"github.com/sisatech/api/deploy"
+	"github.com/sisatech/api/deploy/deploytest"
+)
+
+const (
+	testOrg  = "sisatech"
+	testPool = "pool"
+)
+
+func newTestPool(t *testing.T) (*deploy.Pool, *deploytest.Backend) {
+
+	b := deploytest.NewBackend()
+
+	m, err := deploy.NewManagerWithBackend(nil, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := m.NewPool(testOrg, testPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return p, b
+}
+
+func webArgs(version string) *deploy.SpawnArgs {
+	return &deploy.SpawnArgs{
+		Platform: "kvm",
+		App:      "web/nginx",
+		Version:  version,
+	}
+}
+
+func TestPoolSpawn(t *testing.T) {
+
+	p, b := newTestPool(t)
+
+	id, err := p.Spawn(webArgs("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := p.Instances()
+	if len(list) != 1 || list[0] != id {
+		t.Fatalf("expected instances [%s], got %v", id, list)
+	}
+
+	g, err := b.Goal(testOrg, testPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vm, ok := g.Instances()[id]; !ok || vm.App != "web/nginx" || vm.Version != "v1" {
+		t.Fatalf("instance '%s' not pushed to backend: %+v", id, vm)
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := p.Status(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "running" || status.Platform != "kvm" || status.IP == "" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+func TestPoolSpawnFallback(t *testing.T) {
+
+	p, b := newTestPool(t)
+	b.FailNext(&deploy.ErrPushRejected{
+		Org:  testOrg,
+		Name: testPool,
+		Err:  &api.APIError{StatusCode: 400, Message: "platform full"},
+	})
+
+	args := webArgs("v1")
+	args.Fallback = []string{"aws-sydney"}
+
+	id, err := p.Spawn(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := p.Status(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Platform != "aws-sydney" {
+		t.Fatalf("expected instance on fallback platform, got '%s'", status.Platform)
+	}
+}
+
+func TestPoolSpawnNoFallbackOnOtherErrors(t *testing.T) {
+
+	p, b := newTestPool(t)
+	b.FailNext(errors.New("connection refused"))
+
+	args := webArgs("v1")
+	args.Fallback = []string{"aws-sydney"}
+
+	_, err := p.Spawn(args)
+	if err == nil {
+		t.Fatal("expected spawn to fail")
+	}
+
+	g, err := b.Goal(testOrg, testPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(g.Instances()); n != 0 {
+		t.Fatalf("spawn failed over to another platform after a network error: %d instances", n)
+	}
+}
+
+func TestPoolSpawnWithoutClient(t *testing.T) {
+
+	p, _ := newTestPool(t)
+
+	args := webArgs("v1")
+	args.Platform = ""
+
+	_, err := p.Spawn(args)
+	if err != deploy.ErrNoClient {
+		t.Fatalf("expected ErrNoClient, got %v", err)
+	}
+	if len(p.Instances()) != 0 {
+		t.Fatalf("failed spawn left instances behind: %v", p.Instances())
+	}
+}
+
+func TestPoolScale(t *testing.T) {
+
+	p, b := newTestPool(t)
+	args := webArgs("v1")
+
+	err := p.Scale(args, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Count(args); n != 3 {
+		t.Fatalf("expected 3 instances after scaling up, got %d", n)
+	}
+
+	list := p.Instances()
+
+	err = p.Scale(args, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Count(args); n != 1 {
+		t.Fatalf("expected 1 instance after scaling down, got %d", n)
+	}
+	if got := p.Instances(); len(got) != 1 || got[0] != list[0] {
+		t.Fatalf("expected %s to survive scaling down, got %v", list[0], got)
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := b.Goal(testOrg, testPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(g.Instances()); n != 1 {
+		t.Fatalf("expected backend goal with 1 instance, got %d", n)
+	}
+	if _, err := p.Status(list[1]); err == nil {
+		t.Fatalf("destroyed instance '%s' still reported", list[1])
+	}
+}
+
+func TestPoolScaleFailure(t *testing.T) {
+
+	p, b := newTestPool(t)
+	args := webArgs("v1")
+
+	b.FailNext(errors.New("rejected"))
+
+	err := p.Scale(args, 2)
+	if err == nil {
+		t.Fatal("expected scaling to fail")
+	}
+	if n := p.Count(args); n != 0 {
+		t.Fatalf("rejected scale changed the pool's goal: %d instances", n)
+	}
+}
+
+func TestPoolUpgradeInPlace(t *testing.T) {
+
+	p, b := newTestPool(t)
+
+	err := p.Scale(webArgs("v1"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := p.Instances()
+
+	g, err := b.Goal(testOrg, testPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, vm := range g.Instances() {
+		vm.Version = "v2"
+		g.Attach(id, vm)
+	}
+
+	err = p.SetGoal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range ids {
+		status, err := p.Status(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Version != "v2" {
+			t.Fatalf("instance '%s' not upgraded: version '%s'", id, status.Version)
+		}
+	}
+}
+
+func TestPoolUpgradeReplace(t *testing.T) {
+
+	p, _ := newTestPool(t)
+
+	v1, v2 := webArgs("v1"), webArgs("v2")
+
+	err := p.Scale(v1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := p.Instances()
+
+	tx := p.Tx()
+	err = tx.Scale(v2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tx.Scale(v1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Count(v1) != 0 || p.Count(v2) != 2 {
+		t.Fatalf("expected 0 v1 and 2 v2 instances, got %d and %d", p.Count(v1), p.Count(v2))
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range old {
+		if _, err := p.Status(id); err == nil {
+			t.Fatalf("replaced instance '%s' still reported", id)
+		}
+	}
+	for _, id := range p.Instances() {
+		status, err := p.Status(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Version != "v2" {
+			t.Fatalf("instance '%s' has version '%s'", id, status.Version)
+		}
+	}
+}
+
+func unhealthy(id string, status *deploy.InstanceStatus) error {
+	if status.State != "running" {
+		return errors.New(status.State)
+	}
+	return nil
+}
+
+func TestPoolReconcileRestartsUnhealthy(t *testing.T) {
+
+	p, b := newTestPool(t)
+	p.SetHealthProbe(unhealthy)
+	p.SetRestartPolicy(deploy.RestartOnFailure(0, 0))
+
+	id, err := p.Spawn(webArgs("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = b.SetInstanceState(testOrg, testPool, id, "failed", "out of memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restarted bool
+	for len(p.Events()) > 0 {
+		if e := <-p.Events(); e.Type == deploy.EventRestarted && e.Instance == id {
+			restarted = true
+		}
+	}
+	if !restarted {
+		t.Fatalf("unhealthy instance '%s' was not restarted", id)
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := p.Status(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "running" {
+		t.Fatalf("restarted instance reported as '%s'", status.State)
+	}
+}
+
+func TestPoolReconcileWaitsBackoff(t *testing.T) {
+
+	p, b := newTestPool(t)
+	p.SetHealthProbe(unhealthy)
+	p.SetRestartPolicy(deploy.RestartOnFailure(0, time.Hour))
+
+	id, err := p.Spawn(webArgs("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = b.SetInstanceState(testOrg, testPool, id, "failed", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for len(p.Events()) > 0 {
+		if e := <-p.Events(); e.Type == deploy.EventRestarted {
+			t.Fatalf("instance '%s' restarted before its backoff elapsed", e.Instance)
+		}
+	}
+}
+
+// failingPushes wraps a deploytest.Backend to fail chosen calls to PushGoal,
+// counted from one.
+type failingPushes struct {
+	*deploytest.Backend
+	fail   map[int]error
+	pushes int
+}
+
+func (b *failingPushes) PushGoal(org, name string, g *deploy.DeploymentGoal) error {
+	b.pushes++
+	if err, ok := b.fail[b.pushes]; ok {
+		return err
+	}
+	return b.Backend.PushGoal(org, name, g)
+}
+
+func TestPoolReconcileRestartReattachFails(t *testing.T) {
+
+	b := &failingPushes{
+		Backend: deploytest.NewBackend(),
+		fail:    map[int]error{3: errors.New("unavailable")},
+	}
+
+	m, err := deploy.NewManagerWithBackend(nil, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := m.NewPool(testOrg, testPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetHealthProbe(unhealthy)
+	p.SetRestartPolicy(deploy.RestartOnFailure(0, 0))
+
+	id, err := p.Spawn(webArgs("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = b.SetInstanceState(testOrg, testPool, id, "failed", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The restart detaches the instance, but fails to attach it again.
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failed bool
+	for len(p.Events()) > 0 {
+		if e := <-p.Events(); e.Type == deploy.EventRestartFailed && e.Instance == id {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Fatal("expected the failed restart to be reported")
+	}
+	if list := p.Instances(); len(list) != 1 || list[0] != id {
+		t.Fatalf("instance '%s' lost from the pool by a failed restart: %v", id, list)
+	}
+
+	// The next update reattaches it.
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := b.Goal(testOrg, testPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.Instances()[id]; !ok {
+		t.Fatalf("instance '%s' was not reattached", id)
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := p.Status(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "running" {
+		t.Fatalf("reattached instance reported as '%s'", status.State)
+	}
+}
+
+func TestPoolReconcileExternalChanges(t *testing.T) {
+
+	p, b := newTestPool(t)
+
+	err := p.Scale(webArgs("v1"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := p.Instances()
+
+	err = b.ForceDeleteInstance(testOrg, testPool, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The instance is still in the goal, but VMS no longer reports it.
+	status, err := p.Status(ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "" {
+		t.Fatalf("deleted instance '%s' still reported as '%s'", ids[0], status.State)
+	}
+	if _, err := p.Status(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	b.FailNext(errors.New("unavailable"))
+
+	err = p.Update()
+	if err == nil {
+		t.Fatal("expected update to fail")
+	}
+	if _, err := p.Status(ids[1]); err != nil {
+		t.Fatalf("failed update discarded the last known state: %v", err)
+	}
+}
+
+func TestPoolWatchWithoutClient(t *testing.T) {
+
+	p, _ := newTestPool(t)
+
+	err := p.Watch(context.Background())
+	if err != deploy.ErrNoClient {
+		t.Fatalf("expected ErrNoClient, got %v", err)
+	}
+}
+
+func TestPoolValidationWithoutClient(t *testing.T) {
+
+	p, b := newTestPool(t)
+	p.SetValidation(true)
+
+	_, err := p.Spawn(webArgs("v1"))
+	if err != deploy.ErrNoClient {
+		t.Fatalf("expected ErrNoClient from Spawn, got %v", err)
+	}
+
+	err = p.Validate()
+	if err != deploy.ErrNoClient {
+		t.Fatalf("expected ErrNoClient from Validate, got %v", err)
+	}
+
+	g, err := b.Goal(testOrg, testPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(g.Instances()); n != 0 {
+		t.Fatalf("unvalidated goal pushed with %d instances", n)
+	}
+}
+
+// failingGets wraps a deploytest.Backend to fail every call to GetDeployment.
+type failingGets struct {
+	*deploytest.Backend
+	err error
+}
+
+func (b *failingGets) GetDeployment(org, name string) (*deploy.DeploymentState, error) {
+	return nil, b.err
+}
+
+func TestPoolDrainUnreachable(t *testing.T) {
+
+	b := &failingGets{Backend: deploytest.NewBackend(), err: errors.New("unreachable")}
+
+	m, err := deploy.NewManagerWithBackend(nil, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := m.NewPool(testOrg, testPool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := p.Spawn(webArgs("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.Drain(id, time.Hour)
+	if err != b.err {
+		t.Fatalf("expected the state error, got %v", err)
+	}
+	if list := p.Instances(); len(list) != 1 || list[0] != id {
+		t.Fatalf("instance '%s' destroyed without knowing it had drained: %v", id, list)
+	}
+}
@@ -0,0 +1,93 @@
+package deploy
+
+import (
+	"sort"
+)
+
+// CloseProgress describes how far a Manager has got in cleaning up its Pools.
+// Progress is measured in units of work: every instance is one unit, and so is
+// each Pool that has no instances. Pool names the Pool being deleted, in the
+// form "org::name". Instances lists the instances within it that are being
+// deleted, and is empty once the Pool has been deleted.
+type CloseProgress struct {
+	Pool      string
+	Instances []string
+	Done      int
+	Total     int
+}
+
+// Percent returns the proportion of the overall cleanup that has been
+// completed, between 0 and 100.
+func (x *CloseProgress) Percent() float64 {
+	if x.Total == 0 {
+		return 100
+	}
+	return float64(x.Done) * 100 / float64(x.Total)
+}
+
+// CloseWithProgress is like Close, but calls fn as it works through the
+// Manager's Pools: once before each Pool is deleted, and once after. fn may be
+// nil.
+func (m *Manager) CloseWithProgress(fn func(*CloseProgress)) error {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.closed = true
+
+	list := make([]*Pool, 0)
+	for k, v := range m.pools {
+		if v.adopted {
+			delete(m.pools, k)
+			continue
+		}
+		list = append(list, v)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].key() < list[j].key()
+	})
+
+	work := make([][]string, len(list))
+	total := 0
+	for i, pool := range list {
+		work[i] = pool.Instances()
+		if len(work[i]) == 0 {
+			total++
+		}
+		total += len(work[i])
+	}
+
+	done := 0
+	for i, pool := range list {
+
+		key := pool.key()
+		if fn != nil {
+			fn(&CloseProgress{
+				Pool:      key,
+				Instances: work[i],
+				Done:      done,
+				Total:     total,
+			})
+		}
+
+		err := pool.Close()
+		if err != nil {
+			return err
+		}
+
+		done += len(work[i])
+		if len(work[i]) == 0 {
+			done++
+		}
+
+		if fn != nil {
+			fn(&CloseProgress{
+				Pool:  key,
+				Done:  done,
+				Total: total,
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package deploy
+
+import (
+	"errors"
+)
+
+// ErrQuotaExceeded is returned whenever a change to a Pool's goal would take
+// the number of instances in the Pool, or in all of a Manager's Pools, above
+// the configured maximum.
+var ErrQuotaExceeded = errors.New("instance quota exceeded")
+
+// SetMaxInstances limits the total number of instances across all of the
+// Manager's Pools. Changes that would exceed the limit fail with
+// ErrQuotaExceeded before anything is sent to VMS. Zero means there is no
+// limit.
+func (m *Manager) SetMaxInstances(n int) {
+	m.quotaLock.Lock()
+	defer m.quotaLock.Unlock()
+	m.maxInstances = n
+}
+
+// SetMaxInstances limits the number of instances in the Pool. Changes that
+// would exceed the limit fail with ErrQuotaExceeded before anything is sent to
+// VMS. Zero means there is no limit.
+func (p *Pool) SetMaxInstances(n int) {
+	p.mgr.quotaLock.Lock()
+	defer p.mgr.quotaLock.Unlock()
+	p.maxInstances = n
+}
+
+// checkQuota returns ErrQuotaExceeded if the Pool may not grow to n instances.
+// Changes that do not grow the Pool are always permitted, even if the Pool is
+// already over quota. The caller must hold the quotaLock.
+func (m *Manager) checkQuota(p *Pool, n int) error {
+
+	if n <= m.counts[p] {
+		return nil
+	}
+
+	if p.maxInstances > 0 && n > p.maxInstances {
+		return ErrQuotaExceeded
+	}
+
+	if m.maxInstances > 0 {
+		total := n
+		for k, v := range m.counts {
+			if k != p {
+				total += v
+			}
+		}
+		if total > m.maxInstances {
+			return ErrQuotaExceeded
+		}
+	}
+
+	return nil
+}
+
+// reserve checks that the Pool may grow to n instances, and if so reserves
+// room for them against the Manager's quota until release is called. It
+// returns the previous reservation for the Pool.
+func (m *Manager) reserve(p *Pool, n int) (int, error) {
+
+	m.quotaLock.Lock()
+	defer m.quotaLock.Unlock()
+
+	prev := m.counts[p]
+	err := m.checkQuota(p, n)
+	if err != nil {
+		return prev, err
+	}
+
+	if n > prev {
+		m.counts[p] = n
+	}
+
+	return prev, nil
+}
+
+// release records that the Pool now has n instances.
+func (m *Manager) release(p *Pool, n int) {
+	m.quotaLock.Lock()
+	defer m.quotaLock.Unlock()
+	if n < 0 {
+		delete(m.counts, p)
+		return
+	}
+	m.counts[p] = n
+}
+
+// push enforces leases, validation, and instance quotas, then pushes g to VMS
+// and makes it the Pool's goal. The caller must hold the statusLock.
+func (p *Pool) push(g *DeploymentGoal) error {
+
+	if p.readOnly {
+		return ErrReadOnly
+	}
+
+	if !p.active() {
+		return ErrNotLeaseHolder
+	}
+
+	if p.validation {
+		err := p.validateGoal(g)
+		if err != nil {
+			return err
+		}
+	}
+
+	n := len(g.instances())
+	prev, err := p.mgr.reserve(p, n)
+	if err != nil {
+		return err
+	}
+
+	err = p.mgr.backend.PushGoal(p.org, p.name, g)
+	if err != nil {
+		p.mgr.release(p, prev)
+		return err
+	}
+
+	p.mgr.release(p, n)
+	p.goal = g
+	p.record(g)
+
+	return nil
+}
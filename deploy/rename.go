@@ -0,0 +1,63 @@
+package deploy
+
+// Name returns the name of the Pool's VMS deployment.
+func (p *Pool) Name() string {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+	return p.name
+}
+
+// Rename renames the Pool's VMS deployment without disturbing its instances.
+func (p *Pool) Rename(name string) error {
+
+	if p.mgr.closed {
+		return ErrManagerClosed
+	}
+
+	if p.readOnly {
+		return ErrReadOnly
+	}
+
+	p.mgr.lock.Lock()
+	defer p.mgr.lock.Unlock()
+
+	p.statusLock.Lock()
+	defer p.unlock()
+
+	err := p.mgr.backend.RenameDeployment(p.org, p.name, name)
+	if err != nil {
+		return err
+	}
+
+	delete(p.mgr.pools, p.key())
+	p.name = name
+	p.mgr.pools[p.key()] = p
+
+	return nil
+}
+
+// SetDescription sets the description of the Pool's VMS deployment.
+func (p *Pool) SetDescription(description string) error {
+
+	if p.mgr.closed {
+		return ErrManagerClosed
+	}
+
+	if p.readOnly {
+		return ErrReadOnly
+	}
+
+	p.statusLock.Lock()
+	defer p.unlock()
+
+	md := p.metadata.Copy()
+	md.Description = description
+
+	err := p.mgr.backend.UpdateMetadata(p.org, p.name, md)
+	if err != nil {
+		return err
+	}
+
+	p.metadata = md
+	return nil
+}
@@ -0,0 +1,182 @@
+package deploy
+
+import (
+	"time"
+)
+
+// Probe is a health check evaluated against an instance's last known status
+// every time its Pool is updated. It should return a non-nil error if the
+// instance is unhealthy. Probes are run while the Pool is locked, and must not
+// call back into the Pool.
+type Probe func(id string, status *InstanceStatus) error
+
+// RestartPolicy controls how a Pool reacts to instances that fail their health
+// probe. A failing instance is replaced with a fresh instance built from the
+// same specification, keeping its instance ID.
+type RestartPolicy struct {
+	// MaxRetries is the number of consecutive restarts attempted for an
+	// instance before the Pool gives up on it. Zero means there is no limit.
+	MaxRetries int
+
+	// Backoff is the minimum time to wait before the first restart of an
+	// instance. It doubles after every consecutive restart.
+	Backoff time.Duration
+}
+
+// RestartOnFailure returns a RestartPolicy that restarts an unhealthy instance
+// up to maxRetries times in a row, waiting at least backoff before the first
+// attempt and doubling the wait after every attempt.
+func RestartOnFailure(maxRetries int, backoff time.Duration) *RestartPolicy {
+	return &RestartPolicy{
+		MaxRetries: maxRetries,
+		Backoff:    backoff,
+	}
+}
+
+// restartRecord tracks an unhealthy instance. The since field records when it
+// first failed its probe, and last when it was most recently restarted. If
+// detached is set, the instance was removed from VMS by a restart that could
+// not attach it again, and it is still waiting to be reattached.
+type restartRecord struct {
+	attempts int
+	since    time.Time
+	last     time.Time
+	gaveUp   bool
+	detached bool
+}
+
+// SetHealthProbe sets the Probe used to judge the health of every instance in
+// the Pool. The probe is run against each instance's status at the end of
+// every Update. A nil probe disables health checking.
+func (p *Pool) SetHealthProbe(probe Probe) {
+	p.statusLock.Lock()
+	defer p.statusLock.Unlock()
+	p.probe = probe
+}
+
+// SetRestartPolicy sets the RestartPolicy applied to instances that fail the
+// Pool's health probe. A nil policy disables automatic restarts.
+func (p *Pool) SetRestartPolicy(policy *RestartPolicy) {
+	p.statusLock.Lock()
+	defer p.statusLock.Unlock()
+	p.restartPolicy = policy
+	p.restarts = make(map[string]*restartRecord)
+}
+
+// checkHealth runs the health probe against every known instance and restarts
+// those that fail it according to the restart policy. The caller must hold the
+// statusLock.
+func (p *Pool) checkHealth() {
+
+	if p.probe == nil || !p.active() {
+		return
+	}
+
+	for id := range p.goal.instances() {
+		if r, ok := p.restarts[id]; ok && r.detached {
+			p.reattach(id, r)
+			continue
+		}
+
+		status, ok := p.state.children[id]
+		if !ok {
+			continue
+		}
+
+		err := p.probe(id, status)
+		if err == nil {
+			delete(p.restarts, id)
+			continue
+		}
+
+		p.emit(EventProbeFailed, id, err)
+
+		if p.restartPolicy == nil {
+			continue
+		}
+
+		r, ok := p.restarts[id]
+		if !ok {
+			r = &restartRecord{since: time.Now()}
+			p.restarts[id] = r
+		}
+
+		if r.gaveUp {
+			continue
+		}
+
+		if p.restartPolicy.MaxRetries > 0 && r.attempts >= p.restartPolicy.MaxRetries {
+			r.gaveUp = true
+			p.emit(EventGaveUp, id, err)
+			continue
+		}
+
+		// The first restart waits Backoff from when the instance became
+		// unhealthy; later restarts wait twice as long from the one before.
+		from := r.since
+		if !r.last.IsZero() {
+			from = r.last
+		}
+		wait := p.restartPolicy.Backoff << uint(r.attempts)
+		if time.Since(from) < wait {
+			continue
+		}
+
+		r.attempts++
+		r.last = time.Now()
+
+		err = p.restart(id, r)
+		if err != nil {
+			p.emit(EventRestartFailed, id, err)
+			p.fail("restart", id, err)
+			continue
+		}
+
+		p.emit(EventRestarted, id, nil)
+	}
+}
+
+// restart replaces the named instance by removing it from the goal and then
+// attaching it again. If it cannot be attached again, the instance is kept in
+// the Pool's goal and marked in r, so that later health checks keep trying to
+// reattach it. The caller must hold the statusLock.
+func (p *Pool) restart(id string, r *restartRecord) error {
+
+	vm, _ := p.goal.lookup(id)
+
+	g := p.goal.Copy()
+	g.detachPath(id)
+	err := p.push(g)
+	if err != nil {
+		return err
+	}
+
+	delete(p.state.children, id)
+	delete(p.ready, id)
+
+	g = p.goal.Copy()
+	g.attachPath(id, vm)
+	err = p.push(g)
+	if err != nil {
+		p.goal = g
+		r.detached = true
+		return err
+	}
+
+	return nil
+}
+
+// reattach pushes the Pool's goal again to attach an instance left detached by
+// a failed restart. The caller must hold the statusLock.
+func (p *Pool) reattach(id string, r *restartRecord) {
+
+	err := p.push(p.goal.Copy())
+	if err != nil {
+		p.emit(EventRestartFailed, id, err)
+		p.fail("restart", id, err)
+		return
+	}
+
+	r.detached = false
+	p.emit(EventRestarted, id, nil)
+}
@@ -0,0 +1,125 @@
+package deploy
+
+import (
+	"sort"
+)
+
+// matches reports whether the VM was created from SpawnArgs equivalent to args,
+// ignoring the instance name and group. If args has placement constraints or
+// no platform the platform is ignored too, since it is chosen by the Pool.
+func (x *VM) matches(args *SpawnArgs) bool {
+	if args.Constraints == nil && args.Platform != "" && x.Platform != args.Platform {
+		return false
+	}
+	return x.App == args.App && x.Version == args.Version
+}
+
+// matching returns the alphabetized paths of every instance directly within the
+// subtree named by args.Group that was spawned from equivalent SpawnArgs.
+func (g *DeploymentGoal) matching(args *SpawnArgs) []string {
+
+	list := make([]string, 0)
+
+	sub := g.subtree(args.Group, false)
+	if sub == nil {
+		return list
+	}
+
+	for k, v := range sub.children {
+		if vm, ok := v.(*VM); ok && vm.matches(args) {
+			list = append(list, joinPath(args.Group, k))
+		}
+	}
+
+	sort.Strings(list)
+	return list
+}
+
+// Count returns the number of instances in the Pool's goal that were spawned
+// from SpawnArgs equivalent to args. Only the Platform, App, Version, Group, and
+// Constraints fields of args are considered.
+func (p *Pool) Count(args *SpawnArgs) int {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+	return len(p.goal.matching(args))
+}
+
+// Scale spawns or destroys instances so that exactly n instances spawned from
+// SpawnArgs equivalent to args exist within the Pool, pushing the result to VMS
+// in a single operation. The Name field of args is ignored; new instances always
+// receive randomly generated IDs.
+// When scaling down, instances are destroyed in reverse alphabetical order.
+func (p *Pool) Scale(args *SpawnArgs, n int) error {
+
+	if p.mgr.closed {
+		return ErrManagerClosed
+	}
+
+	p.statusLock.Lock()
+	defer p.unlock()
+
+	g := p.goal.Copy()
+	spawned, destroyed, err := p.stageScale(g, args, n)
+	if err != nil {
+		return err
+	}
+
+	if len(spawned) == 0 && len(destroyed) == 0 {
+		return nil
+	}
+
+	err = p.push(g)
+	if err != nil {
+		return err
+	}
+
+	if fn := p.hooks.OnSpawn; fn != nil {
+		for _, id := range spawned {
+			id := id
+			p.later(func() { fn(id, args) })
+		}
+	}
+
+	if fn := p.hooks.OnDestroy; fn != nil {
+		for _, id := range destroyed {
+			id := id
+			p.later(func() { fn(id) })
+		}
+	}
+
+	return nil
+}
+
+// stageScale modifies g so that exactly n instances spawned from SpawnArgs
+// equivalent to args exist within it, returning the IDs of the instances it
+// added and removed.
+func (p *Pool) stageScale(g *DeploymentGoal, args *SpawnArgs, n int) ([]string, []string, error) {
+
+	if n < 0 {
+		n = 0
+	}
+
+	existing := g.matching(args)
+
+	var spawned, destroyed []string
+
+	for i := len(existing); i < n; i++ {
+		id, err := newID(g, args.Group, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		platform, err := p.place(g, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		g.attachPath(id, args.vm(platform))
+		spawned = append(spawned, id)
+	}
+
+	for i := len(existing) - 1; i >= n; i-- {
+		g.detachPath(existing[i])
+		destroyed = append(destroyed, existing[i])
+	}
+
+	return spawned, destroyed, nil
+}
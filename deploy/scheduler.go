@@ -0,0 +1,185 @@
+package deploy
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ScalingWindow defines bounds on the number of instances a Scheduler should
+// keep running whenever the current time matches its Schedule. A window is
+// active for every minute matched by its cron-like Schedule expression, so
+// "* 9-17 * * 1-5" describes business hours on weekdays. See ParseSchedule for
+// the supported syntax.
+//
+// Min and Max bound the instance count while the window is active. Setting
+// them to the same value pins the count exactly. A Max of zero means there is
+// no upper bound.
+//
+// If more than one window is active at the same time, the one with the highest
+// Priority wins; between windows of equal Priority, the one listed last wins.
+type ScalingWindow struct {
+	Schedule string
+	Min      int
+	Max      int
+	Priority int
+}
+
+type scheduledWindow struct {
+	*ScalingWindow
+	schedule *Schedule
+}
+
+// Scheduler scales the number of instances spawned from a single SpawnArgs
+// within a Pool according to a list of ScalingWindows.
+//
+// Windows act as hard bounds. Anything else that scales the same instances,
+// such as an autoscaler, takes precedence only within the bounds of the active
+// window: whenever the Scheduler is applied, counts below the window's Min are
+// raised to it and counts above its Max are lowered to it, but counts in
+// between are left alone. When no window is active the Scheduler does nothing.
+type Scheduler struct {
+	pool    *Pool
+	args    *SpawnArgs
+	windows []*scheduledWindow
+
+	lock sync.Mutex
+	stop chan struct{}
+}
+
+// NewScheduler returns a Scheduler that scales instances spawned from args
+// within the Pool according to the given windows. The Scheduler does nothing
+// until it is started, or until Apply is called.
+func (p *Pool) NewScheduler(args *SpawnArgs, windows ...*ScalingWindow) (*Scheduler, error) {
+
+	s := new(Scheduler)
+	s.pool = p
+	s.args = args
+
+	for _, w := range windows {
+		if w.Min < 0 || w.Max < 0 || (w.Max > 0 && w.Max < w.Min) {
+			return nil, errors.New("invalid scaling window bounds")
+		}
+
+		schedule, err := ParseSchedule(w.Schedule)
+		if err != nil {
+			return nil, err
+		}
+
+		s.windows = append(s.windows, &scheduledWindow{
+			ScalingWindow: w,
+			schedule:      schedule,
+		})
+	}
+
+	return s, nil
+}
+
+// Active returns the ScalingWindow that takes precedence at time t, or nil if
+// no window is active.
+func (s *Scheduler) Active(t time.Time) *ScalingWindow {
+
+	var active *ScalingWindow
+	for _, w := range s.windows {
+		if !w.schedule.Matches(t) {
+			continue
+		}
+		if active == nil || w.Priority >= active.Priority {
+			active = w.ScalingWindow
+		}
+	}
+
+	return active
+}
+
+// Bounds returns the minimum and maximum instance counts permitted at time t.
+// The final return value is false if no window is active, in which case there
+// are no bounds. A maximum of zero means there is no upper bound.
+func (s *Scheduler) Bounds(t time.Time) (int, int, bool) {
+	w := s.Active(t)
+	if w == nil {
+		return 0, 0, false
+	}
+	return w.Min, w.Max, true
+}
+
+// clamp restricts n to the bounds permitted at time t.
+func (s *Scheduler) clamp(t time.Time, n int) int {
+	min, max, ok := s.Bounds(t)
+	if !ok {
+		return n
+	}
+	if n < min {
+		n = min
+	}
+	if max > 0 && n > max {
+		n = max
+	}
+	return n
+}
+
+// Apply scales the Scheduler's instances into the bounds of the window active
+// at time t.
+func (s *Scheduler) Apply(t time.Time) error {
+
+	count := s.pool.Count(s.args)
+	n := s.clamp(t, count)
+	if n == count {
+		return nil
+	}
+
+	err := s.pool.Scale(s.args, n)
+	if err != nil {
+		return err
+	}
+
+	s.pool.emit(EventScaled, "", nil)
+	return nil
+}
+
+// Start applies the Scheduler immediately and then again every interval
+// until Stop is called. Failures are reported as events on the Pool, and
+// through its Errors channel. Calling Start on a running Scheduler has no
+// effect.
+func (s *Scheduler) Start(interval time.Duration) {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	s.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			err := s.Apply(time.Now())
+			if err != nil {
+				s.pool.emit(EventScheduleFailed, "", err)
+				s.pool.fail("schedule", "", err)
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops a Scheduler started with Start.
+func (s *Scheduler) Stop() {
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
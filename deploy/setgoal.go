@@ -0,0 +1,76 @@
+package deploy
+
+// SetGoal replaces the Pool's entire goal with a copy of g, which is validated
+// and then pushed to VMS in a single operation. It is intended for controllers
+// that compute the desired state of a deployment themselves. Instances that
+// are added or removed relative to the previous goal trigger the OnSpawn and
+// OnDestroy hooks; OnSpawn receives SpawnArgs reconstructed from the goal.
+func (p *Pool) SetGoal(g *DeploymentGoal) error {
+
+	if p.mgr.closed {
+		return ErrManagerClosed
+	}
+
+	g = g.Copy()
+	err := g.validate()
+	if err != nil {
+		return err
+	}
+
+	p.statusLock.Lock()
+	defer p.unlock()
+
+	return p.replace(g, nil)
+}
+
+// replace pushes g as the Pool's new goal and fires the OnSpawn and OnDestroy
+// hooks for every instance added or removed. The args map supplies the
+// SpawnArgs passed to OnSpawn for new instances; any instance missing from it
+// receives SpawnArgs reconstructed from its VM. The caller must hold the
+// statusLock.
+func (p *Pool) replace(g *DeploymentGoal, args map[string]*SpawnArgs) error {
+
+	before := p.goal.instances()
+
+	err := p.push(g)
+	if err != nil {
+		return err
+	}
+
+	after := p.goal.instances()
+
+	if fn := p.hooks.OnSpawn; fn != nil {
+		for id, vm := range after {
+			if _, ok := before[id]; ok {
+				continue
+			}
+			a, ok := args[id]
+			if !ok {
+				group, _ := splitPath(id)
+				a = &SpawnArgs{
+					Platform:      vm.Platform,
+					App:           vm.App,
+					Version:       vm.Version,
+					Group:         group,
+					Labels:        vm.Labels,
+					Customization: vm.Customization,
+					Ports:         vm.Ports,
+					Network:       vm.Network,
+				}
+			}
+			id := id
+			p.later(func() { fn(id, a) })
+		}
+	}
+
+	if fn := p.hooks.OnDestroy; fn != nil {
+		for id := range before {
+			if _, ok := after[id]; !ok {
+				id := id
+				p.later(func() { fn(id) })
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package deploy
+
+import (
+	"sort"
+	"time"
+)
+
+// Snapshot is a consistent, point-in-time copy of everything a Pool knows
+// about its instances. Modifying it has no effect on the Pool.
+type Snapshot struct {
+	// LastUpdated is the time of the Pool's last successful Update.
+	LastUpdated time.Time
+
+	// URLs are the deployment-level URLs of the Pool.
+	URLs []string
+
+	// Instances contains every instance in the Pool's goal, along with
+	// instances that have been removed from the goal but are still reported
+	// by VMS, ordered by ID.
+	Instances []*InstanceSnapshot
+}
+
+// InstanceSnapshot describes a single instance within a Snapshot. Spec is the
+// instance's specification from the goal, or nil if it has been removed from
+// the goal. Status is the instance's last known status from VMS, or nil if VMS
+// has not reported it.
+type InstanceSnapshot struct {
+	ID     string
+	State  InstanceState
+	Spec   *VM
+	Status *InstanceStatus
+}
+
+// Snapshot returns a Snapshot of the Pool, taken while holding the Pool's lock
+// so that the goal and the state it contains agree with one another. Like
+// Status, it does not poll VMS.
+func (p *Pool) Snapshot() *Snapshot {
+
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+
+	s := &Snapshot{
+		LastUpdated: p.lastUpdated,
+		URLs:        p.state.URLs(),
+		Instances:   make([]*InstanceSnapshot, 0),
+	}
+
+	goal := p.goal.instances()
+	for id, vm := range goal {
+		status := p.state.children[id]
+		s.Instances = append(s.Instances, &InstanceSnapshot{
+			ID:     id,
+			State:  computeState(true, status),
+			Spec:   vm.copy(),
+			Status: status.copy(),
+		})
+	}
+
+	for id, status := range p.state.children {
+		if _, ok := goal[id]; ok {
+			continue
+		}
+		s.Instances = append(s.Instances, &InstanceSnapshot{
+			ID:     id,
+			State:  computeState(false, status),
+			Status: status.copy(),
+		})
+	}
+
+	sort.Slice(s.Instances, func(i, j int) bool {
+		return s.Instances[i].ID < s.Instances[j].ID
+	})
+
+	return s
+}
+
+func (x *VM) copy() *VM {
+	n := *x
+	if x.Ports != nil {
+		n.Ports = make([]PortMapping, len(x.Ports))
+		copy(n.Ports, x.Ports)
+	}
+	if x.Labels != nil {
+		n.Labels = make(map[string]string)
+		for k, v := range x.Labels {
+			n.Labels[k] = v
+		}
+	}
+	return &n
+}
+
+func (s *InstanceStatus) copy() *InstanceStatus {
+	if s == nil {
+		return nil
+	}
+	n := *s
+	if s.Ports != nil {
+		n.Ports = make([]PortStatus, len(s.Ports))
+		copy(n.Ports, s.Ports)
+	}
+	if s.URLs != nil {
+		n.URLs = make([]string, len(s.URLs))
+		copy(n.URLs, s.URLs)
+	}
+	return &n
+}
@@ -0,0 +1,35 @@
+package deploy
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrStale is returned alongside an InstanceStatus whenever the status is older
+// than the maximum age the caller was willing to accept.
+var ErrStale = errors.New("instance status is stale")
+
+// LastUpdated returns the time of the Pool's last successful Update, or the
+// zero time if it has never been updated.
+func (p *Pool) LastUpdated() time.Time {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+	return p.lastUpdated
+}
+
+// StatusWithin is like Status, but also returns ErrStale along with the status
+// if the instance's information was last updated more than maxAge ago. An
+// instance that has never been reported by VMS is always stale.
+func (p *Pool) StatusWithin(id string, maxAge time.Duration) (*InstanceStatus, error) {
+
+	status, err := p.Status(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.LastUpdated.IsZero() || time.Since(status.LastUpdated) > maxAge {
+		return status, ErrStale
+	}
+
+	return status, nil
+}
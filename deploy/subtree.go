@@ -0,0 +1,132 @@
+package deploy
+
+import (
+	"strings"
+)
+
+// splitPath separates a slash-separated instance path into the path of its
+// parent subtree and its ID within that subtree.
+func splitPath(path string) (string, string) {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return "", path
+	}
+	return path[:i], path[i+1:]
+}
+
+// subtree returns the nested subtree named by a slash-separated path, or nil if
+// it does not exist. If create is true, any missing subtrees along the path are
+// created.
+func (g *DeploymentGoal) subtree(path string, create bool) *DeploymentGoal {
+
+	if path == "" {
+		return g
+	}
+
+	for _, id := range strings.Split(path, "/") {
+		next := g.Group(id)
+		if next == nil {
+			if !create || g.children[id] != nil {
+				return nil
+			}
+			next = NewDeploymentGoal()
+			g.AttachGroup(id, next)
+		}
+		g = next
+	}
+
+	return g
+}
+
+// Instances returns every VM within the goal and its subtrees, keyed by its
+// slash-separated path. The VMs are copies, and may be modified freely.
+func (g *DeploymentGoal) Instances() map[string]*VM {
+	m := g.instances()
+	for k, v := range m {
+		m[k] = v.copy()
+	}
+	return m
+}
+
+// instances returns every VM within the goal and its subtrees, keyed by its
+// slash-separated path.
+func (g *DeploymentGoal) instances() map[string]*VM {
+	m := make(map[string]*VM)
+	g.collect("", m)
+	return m
+}
+
+func (g *DeploymentGoal) collect(prefix string, m map[string]*VM) {
+	for k, v := range g.children {
+		switch x := v.(type) {
+		case *VM:
+			m[prefix+k] = x
+		case *DeploymentGoal:
+			x.collect(prefix+k+"/", m)
+		}
+	}
+}
+
+// lookup returns the VM at the given slash-separated path.
+func (g *DeploymentGoal) lookup(path string) (*VM, bool) {
+	dir, id := splitPath(path)
+	sub := g.subtree(dir, false)
+	if sub == nil {
+		return nil, false
+	}
+	vm, ok := sub.children[id].(*VM)
+	return vm, ok
+}
+
+// exists reports whether any node is attached at the given slash-separated
+// path.
+func (g *DeploymentGoal) exists(path string) bool {
+	dir, id := splitPath(path)
+	sub := g.subtree(dir, false)
+	if sub == nil {
+		return false
+	}
+	_, ok := sub.children[id]
+	return ok
+}
+
+// groupAvailable reports whether the subtree named by a slash-separated path
+// either exists or could be created, because no other kind of node is in the
+// way.
+func (g *DeploymentGoal) groupAvailable(path string) bool {
+
+	if path == "" {
+		return true
+	}
+
+	for _, id := range strings.Split(path, "/") {
+		node, ok := g.children[id]
+		if !ok {
+			return true
+		}
+		next, ok := node.(*DeploymentGoal)
+		if !ok {
+			return false
+		}
+		g = next
+	}
+
+	return true
+}
+
+// attachPath attaches a VM at the given slash-separated path, creating any
+// missing subtrees along the way. The caller must check that the path is
+// available with groupAvailable.
+func (g *DeploymentGoal) attachPath(path string, vm *VM) {
+	dir, id := splitPath(path)
+	g.subtree(dir, true).Attach(id, vm)
+}
+
+// detachPath removes the VM at the given slash-separated path, if it exists.
+func (g *DeploymentGoal) detachPath(path string) {
+	dir, id := splitPath(path)
+	sub := g.subtree(dir, false)
+	if sub != nil {
+		sub.Detach(id)
+	}
+}
@@ -0,0 +1,63 @@
+package deploy
+
+// PoolSummary contains aggregate information about a Pool, computed from its
+// goal and its last known state.
+//
+// Desired is the number of instances in the goal. Pending, Provisioning,
+// Running, and Failed count those instances by InstanceState, while Stopping
+// counts instances that have been removed from the goal but are still reported
+// by VMS. Versions counts desired instances by app and then by version, and
+// Platforms counts them by platform.
+type PoolSummary struct {
+	Desired      int
+	Pending      int
+	Provisioning int
+	Running      int
+	Failed       int
+	Stopping     int
+	Versions     map[string]map[string]int
+	Platforms    map[string]int
+}
+
+// Summary returns aggregate information about the Pool. Like Status, it does
+// not poll VMS.
+func (p *Pool) Summary() *PoolSummary {
+
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+
+	s := &PoolSummary{
+		Versions:  make(map[string]map[string]int),
+		Platforms: make(map[string]int),
+	}
+
+	goal := p.goal.instances()
+	for id, vm := range goal {
+		s.Desired++
+
+		switch computeState(true, p.state.children[id]) {
+		case Pending:
+			s.Pending++
+		case Provisioning:
+			s.Provisioning++
+		case Running:
+			s.Running++
+		case Failed:
+			s.Failed++
+		}
+
+		if s.Versions[vm.App] == nil {
+			s.Versions[vm.App] = make(map[string]int)
+		}
+		s.Versions[vm.App][vm.Version]++
+		s.Platforms[vm.Platform]++
+	}
+
+	for id := range p.state.children {
+		if _, ok := goal[id]; !ok {
+			s.Stopping++
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,182 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTxDone is returned whenever an operation is performed on a transaction
+// that has already been committed or rolled back.
+var ErrTxDone = errors.New("transaction already committed or rolled back")
+
+// ErrTxConflict is returned by Commit if the Pool's goal was changed by
+// something else after the transaction began.
+var ErrTxConflict = errors.New("pool changed since transaction began")
+
+// Tx stages a set of changes to a Pool's goal so that they can be validated and
+// pushed to VMS together in a single operation. Nothing is sent to VMS until
+// Commit is called. A Tx is not safe for concurrent use.
+type Tx struct {
+	pool *Pool
+	base *DeploymentGoal
+	goal *DeploymentGoal
+	args map[string]*SpawnArgs
+	done bool
+}
+
+// Tx begins a new transaction against the Pool's current goal.
+func (p *Pool) Tx() *Tx {
+	p.statusLock.RLock()
+	defer p.statusLock.RUnlock()
+	return &Tx{
+		pool: p,
+		base: p.goal,
+		goal: p.goal.Copy(),
+		args: make(map[string]*SpawnArgs),
+	}
+}
+
+// Spawn stages a new instance created from args and returns its instance ID.
+// Fallback platforms are not supported within a transaction: only the first
+// platform chosen for the instance is used.
+func (t *Tx) Spawn(args *SpawnArgs) (string, error) {
+
+	if t.done {
+		return "", ErrTxDone
+	}
+
+	id, err := newID(t.goal, args.Group, args.Name)
+	if err != nil {
+		return "", err
+	}
+
+	platform, err := t.pool.place(t.goal, args)
+	if err != nil {
+		return "", err
+	}
+
+	t.goal.attachPath(id, args.vm(platform))
+	t.args[id] = args
+
+	return id, nil
+}
+
+// Destroy stages the removal of the instance named by the given ID.
+func (t *Tx) Destroy(id string) error {
+
+	if t.done {
+		return ErrTxDone
+	}
+
+	if _, ok := t.goal.lookup(id); !ok {
+		return ErrInstanceNotInPool
+	}
+
+	t.goal.detachPath(id)
+	delete(t.args, id)
+
+	return nil
+}
+
+// Scale stages whatever spawns or removals are needed for exactly n instances
+// spawned from SpawnArgs equivalent to args to exist. See Pool.Scale.
+func (t *Tx) Scale(args *SpawnArgs, n int) error {
+
+	if t.done {
+		return ErrTxDone
+	}
+
+	spawned, destroyed, err := t.pool.stageScale(t.goal, args, n)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range spawned {
+		t.args[id] = args
+	}
+	for _, id := range destroyed {
+		delete(t.args, id)
+	}
+
+	return nil
+}
+
+// Validate checks that the combined result of every staged change is a valid
+// goal for the Pool, including that it fits within any instance quotas.
+func (t *Tx) Validate() error {
+
+	if t.done {
+		return ErrTxDone
+	}
+
+	err := t.goal.validate()
+	if err != nil {
+		return err
+	}
+
+	m := t.pool.mgr
+	m.quotaLock.Lock()
+	defer m.quotaLock.Unlock()
+
+	return m.checkQuota(t.pool, len(t.goal.instances()))
+}
+
+// Commit validates the staged changes and pushes them to VMS in a single
+// operation. The transaction is finished afterwards, whether or not Commit
+// succeeds.
+func (t *Tx) Commit() error {
+
+	if t.done {
+		return ErrTxDone
+	}
+
+	err := t.Validate()
+	t.done = true
+	if err != nil {
+		return err
+	}
+
+	p := t.pool
+	if p.mgr.closed {
+		return ErrManagerClosed
+	}
+
+	p.statusLock.Lock()
+	defer p.unlock()
+
+	if p.goal != t.base {
+		return ErrTxConflict
+	}
+
+	return p.replace(t.goal, t.args)
+}
+
+// Rollback discards every staged change. It is safe to call Rollback after
+// Commit, in which case it does nothing.
+func (t *Tx) Rollback() {
+	t.done = true
+	t.goal = nil
+	t.args = nil
+}
+
+// validate checks that every VM within the goal is fully specified and has a
+// valid ID.
+func (g *DeploymentGoal) validate() error {
+
+	for id, vm := range g.instances() {
+		switch {
+		case !validGroup(id):
+			return fmt.Errorf("instance '%s': %v", id, ErrInvalidInstanceID)
+		case vm == nil:
+			return fmt.Errorf("instance '%s' is nil", id)
+		case vm.Platform == "":
+			return fmt.Errorf("instance '%s' has no platform", id)
+		case vm.App == "":
+			return fmt.Errorf("instance '%s' has no app", id)
+		case vm.Version == "":
+			return fmt.Errorf("instance '%s' has no version", id)
+		}
+	}
+
+	return nil
+}
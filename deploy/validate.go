@@ -0,0 +1,181 @@
+package deploy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sisatech/api"
+	"github.com/sisatech/api/apps"
+	"github.com/sisatech/api/platforms"
+)
+
+// ValidationProblem describes one reason a VM in a goal would be rejected.
+// Field is "app", "version", "platform", or "network".
+type ValidationProblem struct {
+	Instance string
+	Field    string
+	Err      error
+}
+
+// ValidationReport is returned by ValidateGoal whenever a goal contains VMs that
+// VMS would reject. Problems is ordered by instance path.
+type ValidationReport struct {
+	Problems []*ValidationProblem
+}
+
+func (r *ValidationReport) Error() string {
+	list := make([]string, len(r.Problems))
+	for i, x := range r.Problems {
+		list[i] = fmt.Sprintf("instance '%s' %s: %v", x.Instance, x.Field, x.Err)
+	}
+	return fmt.Sprintf("goal failed validation: %s", strings.Join(list, "; "))
+}
+
+// ValidateGoal checks every VM in the goal against the named organization
+// before the goal is pushed: that its app exists, that its version resolves to
+// a version ID of that app, that its platform exists, and that its network, if
+// it names one, exists on that platform. It returns nil if the goal is valid, a
+// *ValidationReport listing every problem found if it is not, or some other
+// error if VMS could not be queried. Each distinct app, version, platform, and
+// network is only checked once.
+func ValidateGoal(client *api.Client, org string, g *DeploymentGoal) error {
+
+	err := g.validate()
+	if err != nil {
+		return err
+	}
+
+	type check struct {
+		err   error
+		fatal bool
+	}
+
+	appChecks := make(map[string]*check)
+	versionChecks := make(map[string]*check)
+	networkChecks := make(map[string]map[string]bool)
+
+	report := new(ValidationReport)
+	instances := g.instances()
+
+	names := make([]string, 0)
+	for _, vm := range instances {
+		names = append(names, vm.Platform)
+	}
+
+	platformChecks := make(map[string]bool)
+	if len(names) > 0 {
+		platformChecks, err = platforms.ExistsAll(client, org, names)
+		if err != nil {
+			return err
+		}
+	}
+
+	for id, vm := range instances {
+
+		c, ok := appChecks[vm.App]
+		if !ok {
+			c = new(check)
+			exists, err := apps.Exists(client, org, vm.App)
+			switch {
+			case err != nil:
+				c.err, c.fatal = err, true
+			case !exists:
+				c.err = fmt.Errorf("app '%s' does not exist", vm.App)
+			}
+			appChecks[vm.App] = c
+		}
+		if c.fatal {
+			return c.err
+		}
+		if c.err != nil {
+			report.Problems = append(report.Problems, &ValidationProblem{id, "app", c.err})
+		} else {
+			key := vm.App + "@" + vm.Version
+			v, ok := versionChecks[key]
+			if !ok {
+				v = new(check)
+				resolved, err := apps.ResolveVersionToID(client, org, vm.App, vm.Version)
+				switch {
+				case err == apps.ErrVersionNotExists:
+					v.err = err
+				case err != nil:
+					v.err, v.fatal = err, true
+				case resolved != vm.Version:
+					v.err = fmt.Errorf("'%s' is a tag, not a version ID; use '%s'", vm.Version, resolved)
+				}
+				versionChecks[key] = v
+			}
+			if v.fatal {
+				return v.err
+			}
+			if v.err != nil {
+				report.Problems = append(report.Problems, &ValidationProblem{id, "version", v.err})
+			}
+		}
+
+		if !platformChecks[vm.Platform] {
+			err := fmt.Errorf("platform '%s' does not exist", vm.Platform)
+			report.Problems = append(report.Problems, &ValidationProblem{id, "platform", err})
+			continue
+		}
+
+		if vm.Network == "" {
+			continue
+		}
+
+		networks, ok := networkChecks[vm.Platform]
+		if !ok {
+			list, err := platforms.ListNetworks(client, org, vm.Platform)
+			if err != nil {
+				return err
+			}
+			networks = make(map[string]bool)
+			for _, n := range list {
+				networks[n.Name] = true
+			}
+			networkChecks[vm.Platform] = networks
+		}
+		if !networks[vm.Network] {
+			err := fmt.Errorf("network '%s' does not exist on platform '%s'", vm.Network, vm.Platform)
+			report.Problems = append(report.Problems, &ValidationProblem{id, "network", err})
+		}
+	}
+
+	if len(report.Problems) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(report.Problems, func(i, j int) bool {
+		return report.Problems[i].Instance < report.Problems[j].Instance
+	})
+
+	return report
+}
+
+// Validate checks the Pool's current goal with ValidateGoal. It returns
+// ErrNoClient if the Pool's Manager has no api.Client.
+func (p *Pool) Validate() error {
+	p.statusLock.RLock()
+	g := p.goal.Copy()
+	p.statusLock.RUnlock()
+	return p.validateGoal(g)
+}
+
+// validateGoal checks g with ValidateGoal using the Manager's api.Client.
+func (p *Pool) validateGoal(g *DeploymentGoal) error {
+	if p.mgr.client == nil {
+		return ErrNoClient
+	}
+	return ValidateGoal(p.mgr.client, p.org, g)
+}
+
+// SetValidation controls whether the Pool checks every goal with ValidateGoal
+// before pushing it to VMS. Validation is disabled by default, because it costs
+// several extra requests per push. With validation enabled, a Pool whose
+// Manager has no api.Client fails every push with ErrNoClient.
+func (p *Pool) SetValidation(enabled bool) {
+	p.statusLock.Lock()
+	defer p.statusLock.Unlock()
+	p.validation = enabled
+}
@@ -0,0 +1,69 @@
+package deploy
+
+import (
+	"context"
+	"time"
+)
+
+// spawnPollInterval is how often SpawnAndWait polls VMS while waiting for an
+// instance to be assigned an address.
+const spawnPollInterval = time.Second * 2
+
+// SpawnAndWait is like Spawn, but then polls VMS until the new instance has
+// been assigned an IP address or hostname, and returns its InstanceStatus. If
+// VMS reports that the instance has failed, SpawnAndWait returns an
+// *ErrInstanceFailed. If ctx is done first, it returns ctx.Err(). In these
+// cases, and if an Update fails, the instance is left in the Pool, and its ID
+// is still returned so that the caller may destroy it.
+func (p *Pool) SpawnAndWait(ctx context.Context, args *SpawnArgs) (string, *InstanceStatus, error) {
+
+	id, err := p.Spawn(args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	status, err := p.waitForAddress(ctx, id)
+	return id, status, err
+}
+
+// waitForAddress polls VMS until the named instance has an IP address or
+// hostname.
+func (p *Pool) waitForAddress(ctx context.Context, id string) (*InstanceStatus, error) {
+
+	ticker := time.NewTicker(spawnPollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := p.Update()
+		if err != nil {
+			return nil, err
+		}
+
+		status, err := p.Status(id)
+		if err != nil {
+			return nil, err
+		}
+
+		state, err := p.State(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if state == Failed {
+			return nil, &ErrInstanceFailed{
+				ID:     id,
+				Reason: status.Reason,
+			}
+		}
+
+		if status.IP != "" || status.Hostname != "" {
+			return status.copy(), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// watchMinInterval is the minimum time between consecutive watch requests, so
+// that a VMS that does not hold long-polling requests open is not flooded.
+var watchMinInterval = time.Second
+
+// watchRetryInterval is how long Watch waits before retrying after a failed
+// watch request.
+var watchRetryInterval = time.Second * 5
+
+// WatchDeployment long-polls VMS for a change to the state of the named
+// deployment for the given organization. The etag argument identifies the last
+// state the caller has seen, as returned by a previous call, and may be empty.
+// VMS holds the request open until the state differs from etag, and then
+// returns the new state along with its etag. If VMS reports that nothing has
+// changed, the returned state is nil. The request is abandoned if ctx is done.
+func WatchDeployment(ctx context.Context, client *api.Client, org, name, etag string) (*DeploymentState, string, error) {
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("deployments/api/v3/orgs/%s/deployments/%s?watch=true", org, name), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req = req.WithContext(ctx)
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", responseError(resp)
+	}
+
+	pl, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	state := new(DeploymentState)
+	err = json.Unmarshal(pl, state)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return state, resp.Header.Get("ETag"), nil
+}
+
+// Watch streams changes to the state of the Pool's deployment from VMS and
+// applies them to the Pool as they arrive, exactly as Update would, until ctx
+// is done. It blocks, so it is usually run in its own goroutine, in place of
+// calling Update periodically. Failed watch requests are retried after a short
+// delay and reported through the Pool's Errors channel. Watch always returns a
+// non-nil error: ctx.Err() if ctx is done, or ErrManagerClosed. Watching is
+// not covered by the Manager's Backend, so it fails with ErrNoClient if the
+// Manager has no api.Client.
+func (p *Pool) Watch(ctx context.Context) error {
+
+	if p.mgr.client == nil {
+		return ErrNoClient
+	}
+
+	var etag string
+
+	for {
+		if p.mgr.closed {
+			return ErrManagerClosed
+		}
+
+		start := time.Now()
+
+		state, next, err := WatchDeployment(ctx, p.mgr.client, p.org, p.Name(), etag)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		wait := watchMinInterval - time.Since(start)
+		if err != nil {
+			p.fail("watch", "", err)
+			p.statusLock.RLock()
+			fn := p.hooks.OnUpdateError
+			p.statusLock.RUnlock()
+			if fn != nil {
+				fn(err)
+			}
+			wait = watchRetryInterval
+		} else {
+			etag = next
+			if state != nil {
+				p.statusLock.Lock()
+				p.applyState(state)
+				p.unlock()
+			}
+		}
+
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+}
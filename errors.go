@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody limits how much of an error response body is kept in an
+// APIError.
+const maxErrorBody = 4096
+
+// APIError is returned whenever VMS responds to a request with an unexpected
+// HTTP status. Message contains whatever explanation VMS included in the body
+// of its response, if any.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+// Error returns the HTTP status of the response, followed by the message from
+// VMS if there was one.
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return e.Status
+	}
+	return fmt.Sprintf("%s: %s", e.Status, e.Message)
+}
+
+// NewAPIError builds an APIError from an HTTP response, consuming up to a few
+// kilobytes of its body. It does not close the body.
+func NewAPIError(resp *http.Response) *APIError {
+
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+
+	if resp.Body != nil {
+		data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		if err == nil {
+			e.Message = strings.TrimSpace(string(data))
+		}
+	}
+
+	return e
+}
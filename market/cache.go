@@ -0,0 +1,357 @@
+package market
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoChecksum is returned by Cache.Path when the marketplace does not report a
+// checksum for the version, so it cannot be cached.
+var ErrNoChecksum = errors.New("version has no checksum")
+
+// cacheSuffix is the extension of package files stored in a Cache directory.
+const cacheSuffix = ".pkg"
+
+// indexFile is the name of the file, within a Cache directory, that records
+// the checksum of every version the Cache has looked up.
+const indexFile = "index.json"
+
+// Cache keeps downloaded marketplace packages on disk, so that repeated pulls
+// of the same version are served locally. Packages are keyed by project,
+// version, and checksum, so a version that is republished with different
+// contents is downloaded again. Only versions the marketplace reports a
+// checksum for are cached. It is safe for concurrent use, though packages are
+// downloaded into the cache one at a time, but not for use by several
+// processes sharing a directory.
+//
+// The checksums of versions, including tags, are remembered in an index in the
+// cache directory, so that packages already in the cache can be opened without
+// asking the marketplace. A package whose tag has since moved is noticed when
+// it is next downloaded, but a cached one is served until Refresh is called.
+type Cache struct {
+	market *Market
+	dir    string
+	limit  int64
+
+	lock sync.Mutex
+
+	indexLock sync.Mutex
+	index     map[string]map[string]string
+}
+
+// NewCache returns a Cache that stores packages in dir, creating it if
+// necessary, and downloads them from m, or from the default marketplace if m
+// is nil. Whenever the total size of the cached packages exceeds limit bytes,
+// the least recently used are removed. A limit of zero or less means the cache
+// can grow without bound.
+func NewCache(dir string, limit int64, m *Market) (*Cache, error) {
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		market: m,
+		dir:    dir,
+		limit:  limit,
+	}, nil
+}
+
+func (c *Cache) source() *Market {
+	if c.market == nil {
+		return defaultMarket
+	}
+	return c.market
+}
+
+// key returns the name of the file a package is cached in.
+func (c *Cache) key(project, version, checksum string) string {
+	h := sha256.Sum256([]byte(project + "\x00" + version + "\x00" + strings.ToLower(checksum)))
+	return filepath.Join(c.dir, hex.EncodeToString(h[:])+cacheSuffix)
+}
+
+// checksum looks up the checksum of a version, which may be a ref or a tag,
+// in the index, and otherwise asks the marketplace for it. It returns an empty
+// string if there isn't one, and reports whether the checksum came from the
+// index.
+func (c *Cache) checksum(project, version string) (string, bool, error) {
+
+	c.indexLock.Lock()
+	defer c.indexLock.Unlock()
+
+	err := c.loadIndex()
+	if err != nil {
+		return "", false, err
+	}
+
+	if sum, ok := c.index[project][version]; ok {
+		return sum, true, nil
+	}
+
+	err = c.refresh(project)
+	if err != nil {
+		return "", false, err
+	}
+
+	return c.index[project][version], false, nil
+}
+
+// Refresh asks the marketplace for the checksum of every version of project
+// again, replacing those in the index. Use it to pick up tags that have been
+// moved to a different version since they were last opened.
+func (c *Cache) Refresh(project string) error {
+
+	c.indexLock.Lock()
+	defer c.indexLock.Unlock()
+
+	err := c.loadIndex()
+	if err != nil {
+		return err
+	}
+
+	return c.refresh(project)
+}
+
+// refresh replaces the index entries for project with the versions listed by
+// the marketplace, and saves the index. The caller must hold the indexLock.
+func (c *Cache) refresh(project string) error {
+
+	list, err := c.source().ListVersions(project)
+	if err != nil {
+		return err
+	}
+
+	sums := make(map[string]string)
+	for _, v := range list {
+		if v.SHA256 == "" {
+			continue
+		}
+		sums[v.Ref] = v.SHA256
+		for _, tag := range v.Tags {
+			sums[tag] = v.SHA256
+		}
+	}
+
+	c.index[project] = sums
+
+	return c.saveIndex()
+}
+
+// loadIndex reads the index from the cache directory, if it hasn't been read
+// already. The caller must hold the indexLock.
+func (c *Cache) loadIndex() error {
+
+	if c.index != nil {
+		return nil
+	}
+
+	index := make(map[string]map[string]string)
+
+	data, err := ioutil.ReadFile(filepath.Join(c.dir, indexFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &index)
+		if err != nil {
+			// A corrupt index only costs extra lookups, so start afresh.
+			index = make(map[string]map[string]string)
+		}
+	}
+
+	c.index = index
+	return nil
+}
+
+// saveIndex atomically writes the index to the cache directory. The caller must
+// hold the indexLock.
+func (c *Cache) saveIndex() error {
+
+	data, err := json.Marshal(c.index)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(c.dir, "."+indexFile+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp, filepath.Join(c.dir, indexFile))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// resolve returns the path of the cached package for a version, downloading
+// it if necessary. If the version looked up in the index no longer matches what
+// the marketplace serves, the index is refreshed and the download retried. It
+// returns an empty path, and no error, if the version has no checksum.
+func (c *Cache) resolve(ctx context.Context, project, version string) (string, error) {
+
+	sum, indexed, err := c.checksum(project, version)
+	if err != nil || sum == "" {
+		return "", err
+	}
+
+	path, err := c.fetch(ctx, project, version, sum)
+	if err != ErrChecksumMismatch || !indexed {
+		return path, err
+	}
+
+	err = c.Refresh(project)
+	if err != nil {
+		return "", err
+	}
+
+	sum, _, err = c.checksum(project, version)
+	if err != nil || sum == "" {
+		return "", err
+	}
+
+	return c.fetch(ctx, project, version, sum)
+}
+
+// Open returns the package for a version of a marketplace project, from the
+// cache if it is there, and otherwise downloading it into the cache first. If
+// the version has no checksum it is downloaded directly, bypassing the cache.
+// The caller must close the returned io.ReadCloser.
+func (c *Cache) Open(ctx context.Context, project, version string) (io.ReadCloser, error) {
+
+	path, err := c.resolve(ctx, project, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		r, _, err := c.source().DownloadContext(ctx, project, version)
+		return r, err
+	}
+
+	return os.Open(path)
+}
+
+// Path is like Open, but returns the path of the cached package file instead
+// of opening it, such as to pass to another program. The file may be removed
+// by a later call that makes the cache exceed its limit. Versions without a
+// checksum cannot be cached, and return ErrNoChecksum.
+func (c *Cache) Path(ctx context.Context, project, version string) (string, error) {
+
+	path, err := c.resolve(ctx, project, version)
+	if err != nil {
+		return "", err
+	}
+
+	if path == "" {
+		return "", ErrNoChecksum
+	}
+
+	return path, nil
+}
+
+// fetch returns the path of a cached package, downloading it first if it is
+// missing.
+func (c *Cache) fetch(ctx context.Context, project, version, sum string) (string, error) {
+
+	path := c.key(project, version, sum)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, err := os.Stat(path)
+	if err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return path, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	info, err := c.source().downloadToFile(ctx, project, version, path, sum)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.EqualFold(info.Checksum, sum) {
+		// The download was verified against a different checksum than the
+		// version listing reported, so the cache key would be wrong.
+		os.Remove(path)
+		return "", ErrChecksumMismatch
+	}
+
+	err = c.evict(path)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// evict removes the least recently used packages until the cache is within
+// its limit, never removing keep. The caller must hold the lock.
+func (c *Cache) evict(keep string) error {
+
+	if c.limit <= 0 {
+		return nil
+	}
+
+	list, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	files := make([]os.FileInfo, 0)
+	for _, info := range list {
+		if info.Mode().IsRegular() && strings.HasSuffix(info.Name(), cacheSuffix) {
+			files = append(files, info)
+			total += info.Size()
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	for _, info := range files {
+		if total <= c.limit {
+			break
+		}
+		path := filepath.Join(c.dir, info.Name())
+		if path == keep {
+			continue
+		}
+		err = os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= info.Size()
+	}
+
+	return nil
+}
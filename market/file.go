@@ -0,0 +1,99 @@
+package market
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned whenever a downloaded package does not match
+// the checksum reported by the marketplace.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// DownloadToFile downloads the package for a version of a marketplace project
+// to the named file. The package is written to a temporary file in the same
+// directory, which is only renamed into place once its size and checksum have
+// been verified against those reported by the marketplace, so the named file
+// is never left incomplete. If anything fails, or ctx is done before the
+// download finishes, the temporary file is removed and the named file is left
+// untouched.
+func DownloadToFile(ctx context.Context, project, version, path string) (*PackageInfo, error) {
+	return defaultMarket.DownloadToFile(ctx, project, version, path)
+}
+
+// DownloadToFile downloads the package for a version of a marketplace project
+// to the named file. See DownloadToFile.
+func (m *Market) DownloadToFile(ctx context.Context, project, version, path string) (*PackageInfo, error) {
+	return m.downloadToFile(ctx, project, version, path, "")
+}
+
+// downloadToFile implements DownloadToFile, verifying the package against
+// checksum if the marketplace doesn't report one with the download.
+func (m *Market) downloadToFile(ctx context.Context, project, version, path, checksum string) (*PackageInfo, error) {
+
+	body, info, err := m.download(ctx, project, version, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	if info.Checksum == "" {
+		info.Checksum = checksum
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	tmp := f.Name()
+	done := false
+	defer func() {
+		if !done {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	if info.Size >= 0 && n != info.Size {
+		return nil, fmt.Errorf("downloaded %d bytes, expected %d", n, info.Size)
+	}
+
+	if info.Checksum != "" && !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), info.Checksum) {
+		return nil, ErrChecksumMismatch
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return nil, err
+	}
+	done = true
+
+	return info, nil
+}
@@ -0,0 +1,85 @@
+package market
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+type importPL struct {
+	Project string `json:"project"`
+	Version string `json:"version"`
+}
+
+type importResponse struct {
+	Version string `json:"version"`
+}
+
+// ImportToRepository asks VMS to copy a version of a marketplace project into
+// the organization's repository as a new version of the app at destPath,
+// creating the app if necessary. The copy happens server-side, so the package
+// never passes through the client, and any marketplace entitlements are those
+// of the client's user. It returns the ID of the new version.
+func ImportToRepository(client *api.Client, project, version, org, destPath string) (string, error) {
+
+	p := path.Clean("/" + destPath)
+	dir, base := path.Split(p)
+	dir = strings.Trim(dir, "/")
+	if base == "" {
+		return "", errors.New("app path must not be empty")
+	}
+
+	pl, err := json.Marshal(&importPL{
+		Project: project,
+		Version: version,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("op", "import-market")
+	query.Set("dir", dir)
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("images/api/v3/orgs/%s/objects/%s?%s", org, base, query.Encode()), bytes.NewReader(pl))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", api.NewAPIError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	ir := new(importResponse)
+	err = json.Unmarshal(data, ir)
+	if err != nil {
+		return "", err
+	}
+
+	if ir.Version == "" {
+		return "", errors.New("import response did not include a version")
+	}
+
+	return ir.Version, nil
+}
@@ -0,0 +1,38 @@
+package market
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// List returns a page of the marketplace's projects, with DefaultPageSize
+// projects per page, starting from page zero. If category is not empty only
+// projects in that category are listed.
+func List(category string, page int) (*Results, error) {
+	return defaultMarket.List(category, page)
+}
+
+// List returns a page of the marketplace's projects. See List.
+func (m *Market) List(category string, page int) (*Results, error) {
+
+	q := url.Values{}
+	if category != "" {
+		q.Set("category", category)
+	}
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(DefaultPageSize))
+
+	results := new(Results)
+	err := m.getJSON(m.url("apps?%s", q.Encode()), results)
+	if err != nil {
+		return nil, err
+	}
+
+	if results.Projects == nil {
+		results.Projects = make([]*ProjectSummary, 0)
+	}
+	results.Page = page
+	results.PageSize = DefaultPageSize
+
+	return results, nil
+}
@@ -0,0 +1,136 @@
+package market
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// ErrAnonymous is returned whenever an operation that requires authentication
+// is attempted on an anonymous Market, such as the one used by the
+// package-level functions.
+var ErrAnonymous = errors.New("marketplace operation requires an authenticated client")
+
+// PricingModel describes how a marketplace project is charged for.
+type PricingModel string
+
+// Pricing models supported by the marketplace.
+const (
+	Free         PricingModel = "free"
+	OneOff       PricingModel = "one-off"
+	Subscription PricingModel = "subscription"
+)
+
+// Pricing is what a marketplace project costs. Price is in Currency, and is
+// charged once or monthly depending on the Model. It is ignored for free
+// projects.
+type Pricing struct {
+	Model    PricingModel `json:"model"`
+	Price    float64      `json:"price,omitempty"`
+	Currency string       `json:"currency,omitempty"`
+}
+
+// Listing is the publisher-maintained information shown for a marketplace
+// project. Fields that are nil are left unchanged by UpdateListing.
+type Listing struct {
+	Summary     *string   `json:"summary,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Categories  *[]string `json:"categories,omitempty"`
+	Pricing     *Pricing  `json:"pricing,omitempty"`
+}
+
+// UpdateListing changes the listing of a marketplace project published by the
+// Market's client, without publishing a new version.
+func (m *Market) UpdateListing(project string, listing *Listing) error {
+
+	if m.client == nil {
+		return ErrAnonymous
+	}
+
+	pl, err := json.Marshal(listing)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, m.url("projects/%s", project), bytes.NewReader(pl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrProjectNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return api.NewAPIError(resp)
+	}
+
+	return nil
+}
+
+// SetIcon replaces the icon of a marketplace project published by the Market's
+// client with the image read from r. The filename is passed to the marketplace
+// so that it can determine the image format, and should have an extension such
+// as ".png".
+func (m *Market) SetIcon(project, filename string, r io.Reader) error {
+
+	if m.client == nil {
+		return ErrAnonymous
+	}
+
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, r)
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, m.url("projects/%s/icon", project), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := m.do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrProjectNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return api.NewAPIError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package market
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// ErrProjectNotFound is returned whenever the marketplace has no project at
+// the given path.
+var ErrProjectNotFound = errors.New("project not found")
+
+// Market is a connection to a marketplace. The package-level functions use an
+// anonymous connection to the official marketplace unless SetDefault is
+// called; use New to access the marketplace of a particular VMS as an
+// authenticated user, such as to fetch private or entitlement-gated projects,
+// or NewMirrored to use alternative marketplace endpoints.
+type Market struct {
+	client  *api.Client
+	domains []string
+}
+
+// defaultMarket is used by the package-level functions.
+var defaultMarket = new(Market)
+
+// New returns a Market that sends its requests through client, using the
+// client's domain and credentials. A nil client gives an anonymous connection
+// to the official marketplace.
+func New(client *api.Client) *Market {
+	return &Market{client: client}
+}
+
+// NewMirrored returns an anonymous Market that sends its requests to the
+// first of the given domains, such as "https://market.example.com", failing
+// over to each of the others in turn if a domain can't be reached or responds
+// with a server error. With no domains it connects to the official
+// marketplace.
+func NewMirrored(domains ...string) *Market {
+	list := make([]string, 0, len(domains))
+	for _, d := range domains {
+		list = append(list, strings.TrimSuffix(d, "/"))
+	}
+	return &Market{domains: list}
+}
+
+// SetDefault replaces the Market used by the package-level functions. A nil
+// Market restores the anonymous connection to the official marketplace.
+func SetDefault(m *Market) {
+	if m == nil {
+		m = new(Market)
+	}
+	defaultMarket = m
+}
+
+// domain returns the domain the Market sends requests to first.
+func (m *Market) domain() string {
+	if len(m.domains) > 0 {
+		return m.domains[0]
+	}
+	return api.OfficialDomain
+}
+
+// url appends a formatted path to the marketplace's API root.
+func (m *Market) url(format string, a ...interface{}) string {
+	path := fmt.Sprintf(format, a...)
+	if m.client != nil {
+		return m.client.URL("market/api/%s", path)
+	}
+	return fmt.Sprintf("%s/market/api/%s", m.domain(), path)
+}
+
+// do sends a request to the marketplace, which must have been built with a
+// URL from url, failing over to the Market's mirrors if necessary.
+func (m *Market) do(req *http.Request) (*http.Response, error) {
+
+	if m.client != nil {
+		return m.client.Do(req)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if len(m.domains) < 2 {
+		return resp, err
+	}
+
+	path := strings.TrimPrefix(req.URL.String(), m.domains[0])
+
+	for _, mirror := range m.domains[1:] {
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			break
+		}
+
+		api.Log.Debug("Market unavailable, trying mirror", "mirror", mirror, "err", err)
+
+		next, cerr := mirrorRequest(req, mirror+path)
+		if cerr != nil {
+			break
+		}
+
+		if err == nil {
+			resp.Body.Close()
+		}
+		resp, err = http.DefaultClient.Do(next)
+	}
+
+	return resp, err
+}
+
+// mirrorRequest copies req so that it can be resent to another URL.
+func mirrorRequest(req *http.Request, url string) (*http.Request, error) {
+
+	next, err := http.NewRequest(req.Method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	next = next.WithContext(req.Context())
+	next.Header = req.Header.Clone()
+
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("request body cannot be resent")
+		}
+		next.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.ContentLength = req.ContentLength
+	}
+
+	return next, nil
+}
+
+// getJSON fetches url from the marketplace and unmarshals the response body
+// into v.
+func (m *Market) getJSON(url string, v interface{}) error {
+
+	api.Log.Debug("Market request", "url", url)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := m.do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrProjectNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return api.NewAPIError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
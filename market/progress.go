@@ -0,0 +1,36 @@
+package market
+
+import (
+	"io"
+)
+
+// ProgressFunc is called as a download is read with the number of bytes read
+// so far and the total size of the download, which is -1 if it is unknown.
+type ProgressFunc func(read, total int64)
+
+type progressReader struct {
+	io.ReadCloser
+	read  int64
+	total int64
+	fn    ProgressFunc
+}
+
+// NewProgressReader wraps r so that fn is called after every read from it,
+// such as to draw a progress bar. total is the expected size of r, such as
+// the Size reported by Download.
+func NewProgressReader(r io.ReadCloser, total int64, fn ProgressFunc) io.ReadCloser {
+	return &progressReader{
+		ReadCloser: r,
+		total:      total,
+		fn:         fn,
+	}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		p.fn(p.read, p.total)
+	}
+	return n, err
+}
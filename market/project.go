@@ -0,0 +1,44 @@
+package market
+
+import (
+	"time"
+)
+
+// Project describes a marketplace project in full. Readme is the project's
+// readme document in Markdown, and Screenshots and Icon are URLs of images
+// supplied by the publisher. Downloads counts every download of every version.
+type Project struct {
+	Project       string    `json:"project"`
+	Publisher     string    `json:"publisher"`
+	Summary       string    `json:"summary"`
+	Description   string    `json:"description"`
+	License       string    `json:"license"`
+	Readme        string    `json:"readme"`
+	Icon          string    `json:"icon"`
+	Screenshots   []string  `json:"screenshots"`
+	Categories    []string  `json:"categories"`
+	Downloads     int64     `json:"downloads"`
+	LatestVersion string    `json:"latest_version"`
+	Updated       time.Time `json:"updated"`
+}
+
+// GetProject returns the details of a marketplace project.
+func GetProject(project string) (*Project, error) {
+	return defaultMarket.GetProject(project)
+}
+
+// GetProject returns the details of a marketplace project.
+func (m *Market) GetProject(project string) (*Project, error) {
+
+	p := new(Project)
+	err := m.getJSON(m.url("projects/%s", project), p)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Project == "" {
+		p.Project = project
+	}
+
+	return p, nil
+}
@@ -1,34 +1,164 @@
 package market
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/sisatech/api"
 )
 
-// Download ..
-func Download(project, version string) (io.ReadCloser, error) {
+// PackageInfo describes a package returned by Download. Size is the size of
+// the whole package, or -1 if the marketplace did not report it. Checksum is
+// the hex-encoded SHA-256 checksum of the package, and is empty if the
+// marketplace did not report one.
+type PackageInfo struct {
+	Size        int64
+	Checksum    string
+	ContentType string
+}
+
+// Download fetches the package for a version of a marketplace project. The
+// caller must close the returned io.ReadCloser. Wrap it with
+// NewProgressReader to report progress as it is read.
+func Download(project, version string) (io.ReadCloser, *PackageInfo, error) {
+	return defaultMarket.Download(project, version)
+}
 
-	url := fmt.Sprintf("%s/market/api/apps/%s?refs=%s", api.OfficialDomain, project, version)
-	api.Log.Debug("Download URL: %v", url)
+// Download fetches the package for a version of a marketplace project. See
+// Download.
+func (m *Market) Download(project, version string) (io.ReadCloser, *PackageInfo, error) {
+	return m.download(context.Background(), project, version, 0)
+}
+
+// DownloadContext is like Download, but aborts the download and closes its
+// connection as soon as ctx is done, even if the returned io.ReadCloser is
+// part way through being read.
+func DownloadContext(ctx context.Context, project, version string) (io.ReadCloser, *PackageInfo, error) {
+	return defaultMarket.DownloadContext(ctx, project, version)
+}
+
+// DownloadContext is like Download, but aborts the download when ctx is done.
+// See DownloadContext.
+func (m *Market) DownloadContext(ctx context.Context, project, version string) (io.ReadCloser, *PackageInfo, error) {
+	return m.download(ctx, project, version, 0)
+}
+
+// DownloadResume is like Download, but the returned io.ReadCloser starts offset
+// bytes into the package, such as to continue an interrupted download. If the
+// marketplace does not support partial downloads, the first offset bytes are
+// fetched and discarded.
+func DownloadResume(project, version string, offset int64) (io.ReadCloser, *PackageInfo, error) {
+	return defaultMarket.DownloadResume(project, version, offset)
+}
+
+// DownloadResume is like Download, but starts offset bytes into the package.
+// See DownloadResume.
+func (m *Market) DownloadResume(project, version string, offset int64) (io.ReadCloser, *PackageInfo, error) {
+	return m.download(context.Background(), project, version, offset)
+}
+
+// DownloadResumeContext is like DownloadResume, but aborts the download when
+// ctx is done.
+func DownloadResumeContext(ctx context.Context, project, version string, offset int64) (io.ReadCloser, *PackageInfo, error) {
+	return defaultMarket.DownloadResumeContext(ctx, project, version, offset)
+}
+
+// DownloadResumeContext is like DownloadResume, but aborts the download when
+// ctx is done.
+func (m *Market) DownloadResumeContext(ctx context.Context, project, version string, offset int64) (io.ReadCloser, *PackageInfo, error) {
+	return m.download(ctx, project, version, offset)
+}
+
+// download implements DownloadResume, aborting the request if ctx is done.
+func (m *Market) download(ctx context.Context, project, version string, offset int64) (io.ReadCloser, *PackageInfo, error) {
+
+	url := m.url("apps/%s?refs=%s", project, version)
+	api.Log.Debug("Download", "url", url, "offset", offset)
 
 	r, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	r = r.WithContext(ctx)
 
-	client := http.DefaultClient
-	resp, err := client.Do(r)
+	if offset > 0 {
+		r.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := m.do(r)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(resp.Status)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil, ErrProjectNotFound
+		}
+		return nil, nil, api.NewAPIError(resp)
+	}
+
+	info := &PackageInfo{
+		Size:        resp.ContentLength,
+		Checksum:    resp.Header.Get("X-Checksum-Sha256"),
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+
+	if resp.StatusCode == http.StatusPartialContent {
+		cr := resp.Header.Get("Content-Range")
+		if start := rangeStart(cr); start != offset {
+			resp.Body.Close()
+			return nil, nil, fmt.Errorf("requested download from byte %d but received range '%s'", offset, cr)
+		}
+		info.Size = rangeTotal(cr)
+	} else if offset > 0 {
+		_, err = io.CopyN(ioutil.Discard, resp.Body, offset)
+		if err != nil {
+			resp.Body.Close()
+			return nil, nil, err
+		}
+	}
+
+	return resp.Body, info, nil
+}
+
+// rangeStart returns the first byte position from a Content-Range header such
+// as "bytes 100-999/1000", or -1 if it is missing or malformed.
+func rangeStart(header string) int64 {
+
+	header = strings.TrimPrefix(header, "bytes ")
+
+	i := strings.Index(header, "-")
+	if i < 0 {
+		return -1
+	}
+
+	n, err := strconv.ParseInt(header[:i], 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return n
+}
+
+// rangeTotal returns the complete length from a Content-Range header such as
+// "bytes 100-999/1000", or -1 if it is missing or unknown.
+func rangeTotal(header string) int64 {
+
+	i := strings.LastIndex(header, "/")
+	if i < 0 {
+		return -1
+	}
+
+	n, err := strconv.ParseInt(header[i+1:], 10, 64)
+	if err != nil {
+		return -1
 	}
 
-	return resp.Body, nil
+	return n
 }
@@ -0,0 +1,174 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// resumeBackoff is how long a resumingReader waits before its first attempt to
+// resume a download. It doubles after every consecutive failure.
+const resumeBackoff = time.Second
+
+// ErrPackageChanged is returned when a resumed download reports a different
+// size or checksum than the original download, such as when the tag being
+// downloaded was moved to another version part way through.
+var ErrPackageChanged = errors.New("package changed while downloading")
+
+type resumingReader struct {
+	ctx     context.Context
+	market  *Market
+	project string
+	version string
+	retries int
+	info    *PackageInfo
+
+	body   io.ReadCloser
+	offset int64
+	failed int
+	closed bool
+}
+
+// DownloadWithRetry is like Download, but if reading the package fails part
+// way through, the returned io.ReadCloser transparently resumes the download
+// from where it stopped. It gives up after retries consecutive failures and
+// returns the last error. If a resumed download reports a different size or
+// checksum than the original one, reading fails with ErrPackageChanged.
+func DownloadWithRetry(project, version string, retries int) (io.ReadCloser, *PackageInfo, error) {
+	return defaultMarket.DownloadWithRetry(project, version, retries)
+}
+
+// DownloadWithRetry is like Download, but resumes the download if it fails
+// part way through. See DownloadWithRetry.
+func (m *Market) DownloadWithRetry(project, version string, retries int) (io.ReadCloser, *PackageInfo, error) {
+	return m.DownloadWithRetryContext(context.Background(), project, version, retries)
+}
+
+// DownloadWithRetryContext is like DownloadWithRetry, but aborts the download,
+// including any wait before resuming it, when ctx is done.
+func DownloadWithRetryContext(ctx context.Context, project, version string, retries int) (io.ReadCloser, *PackageInfo, error) {
+	return defaultMarket.DownloadWithRetryContext(ctx, project, version, retries)
+}
+
+// DownloadWithRetryContext is like DownloadWithRetry, but aborts the download
+// when ctx is done. See DownloadWithRetryContext.
+func (m *Market) DownloadWithRetryContext(ctx context.Context, project, version string, retries int) (io.ReadCloser, *PackageInfo, error) {
+
+	body, info, err := m.download(ctx, project, version, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &resumingReader{
+		ctx:     ctx,
+		market:  m,
+		project: project,
+		version: version,
+		retries: retries,
+		info:    info,
+		body:    body,
+	}, info, nil
+}
+
+func (r *resumingReader) Read(p []byte) (int, error) {
+
+	if r.closed {
+		return 0, errors.New("read from closed download")
+	}
+
+	for {
+		if r.body == nil {
+			body, info, err := r.market.download(r.ctx, r.project, r.version, r.offset)
+			if err != nil {
+				if !r.retry(err) {
+					return 0, err
+				}
+				continue
+			}
+			if !r.same(info) {
+				body.Close()
+				return 0, ErrPackageChanged
+			}
+			r.body = body
+		}
+
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if n > 0 {
+			r.failed = 0
+		}
+
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+
+		r.body.Close()
+		r.body = nil
+
+		if n > 0 {
+			return n, nil
+		}
+
+		if !r.retry(err) {
+			if r.ctx.Err() != nil {
+				return 0, r.ctx.Err()
+			}
+			return 0, err
+		}
+	}
+}
+
+// same reports whether a resumed download could be of the same package as the
+// original download, judging by whichever of their sizes and checksums both
+// report.
+func (r *resumingReader) same(info *PackageInfo) bool {
+
+	if r.info.Size >= 0 && info.Size >= 0 && r.info.Size != info.Size {
+		return false
+	}
+
+	if r.info.Checksum != "" && info.Checksum != "" && !strings.EqualFold(r.info.Checksum, info.Checksum) {
+		return false
+	}
+
+	return true
+}
+
+// retry records a failure, and reports whether another attempt should be made
+// after waiting for the backoff period. It returns false without waiting out
+// the backoff if the context is done.
+func (r *resumingReader) retry(err error) bool {
+
+	if r.failed >= r.retries || r.ctx.Err() != nil {
+		return false
+	}
+
+	wait := resumeBackoff << uint(r.failed)
+	r.failed++
+
+	api.Log.Debug("Resuming download", "project", r.project, "offset", r.offset, "err", err)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-r.ctx.Done():
+		return false
+	}
+}
+
+func (r *resumingReader) Close() error {
+	r.closed = true
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
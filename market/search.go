@@ -0,0 +1,92 @@
+package market
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// DefaultPageSize is the number of results requested per page when no page
+// size is given.
+const DefaultPageSize = 50
+
+// SearchFilters narrows the results of Search. Publisher and Category, if not
+// empty, restrict the results to projects from that publisher or in that
+// category.
+//
+// Page selects which page of results to return, starting from zero, and
+// PageSize the number of results per page, which defaults to
+// DefaultPageSize.
+type SearchFilters struct {
+	Publisher string
+	Category  string
+	Page      int
+	PageSize  int
+}
+
+// ProjectSummary briefly describes a marketplace project. Project is the path
+// to pass to Download, and LatestVersion is its newest published version.
+type ProjectSummary struct {
+	Project       string `json:"project"`
+	Publisher     string `json:"publisher"`
+	Summary       string `json:"summary"`
+	LatestVersion string `json:"latest_version"`
+}
+
+// Results is a single page of marketplace projects. Total is the number of
+// matching projects across all pages.
+type Results struct {
+	Projects []*ProjectSummary `json:"results"`
+	Page     int               `json:"-"`
+	PageSize int               `json:"-"`
+	Total    int               `json:"total"`
+}
+
+// More reports whether there are further pages of results after this one.
+func (r *Results) More() bool {
+	return (r.Page+1)*r.PageSize < r.Total
+}
+
+// Search returns a page of marketplace projects matching the query. filters
+// may be nil.
+func Search(query string, filters *SearchFilters) (*Results, error) {
+	return defaultMarket.Search(query, filters)
+}
+
+// Search returns a page of marketplace projects matching the query. filters
+// may be nil.
+func (m *Market) Search(query string, filters *SearchFilters) (*Results, error) {
+
+	if filters == nil {
+		filters = new(SearchFilters)
+	}
+
+	size := filters.PageSize
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+
+	q := url.Values{}
+	q.Set("q", query)
+	if filters.Publisher != "" {
+		q.Set("publisher", filters.Publisher)
+	}
+	if filters.Category != "" {
+		q.Set("category", filters.Category)
+	}
+	q.Set("page", strconv.Itoa(filters.Page))
+	q.Set("per_page", strconv.Itoa(size))
+
+	results := new(Results)
+	err := m.getJSON(m.url("search?%s", q.Encode()), results)
+	if err != nil {
+		return nil, err
+	}
+
+	if results.Projects == nil {
+		results.Projects = make([]*ProjectSummary, 0)
+	}
+	results.Page = filters.Page
+	results.PageSize = size
+
+	return results, nil
+}
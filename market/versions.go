@@ -0,0 +1,40 @@
+package market
+
+import (
+	"sort"
+	"time"
+)
+
+// Version is a published version of a marketplace project. Ref is the value
+// to pass to Download, and Tags are any other names the version is known by,
+// such as "latest".
+type Version struct {
+	Ref       string    `json:"ref"`
+	Tags      []string  `json:"tags"`
+	Published time.Time `json:"published"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+}
+
+// ListVersions returns every published version of a marketplace project,
+// newest first.
+func ListVersions(project string) ([]*Version, error) {
+	return defaultMarket.ListVersions(project)
+}
+
+// ListVersions returns every published version of a marketplace project,
+// newest first.
+func (m *Market) ListVersions(project string) ([]*Version, error) {
+
+	list := make([]*Version, 0)
+	err := m.getJSON(m.url("projects/%s/versions", project), &list)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Published.After(list[j].Published)
+	})
+
+	return list, nil
+}
@@ -0,0 +1,90 @@
+package market
+
+import (
+	"context"
+	"time"
+)
+
+// WatchInterval is how often WatchProject polls the marketplace for new
+// versions.
+var WatchInterval = time.Minute * 5
+
+// Release describes a new version of a marketplace project found by
+// WatchProject. If the marketplace could not be polled, Version is nil and Err
+// describes why; watching continues regardless.
+type Release struct {
+	Project string
+	Version *Version
+	Err     error
+}
+
+// WatchProject polls the marketplace every WatchInterval for new versions of
+// a project, sending a Release on the returned channel for each one found,
+// oldest first. Versions that exist when WatchProject is called are not
+// reported. The channel is closed once ctx is done.
+func WatchProject(ctx context.Context, project string) (<-chan *Release, error) {
+	return defaultMarket.WatchProject(ctx, project)
+}
+
+// WatchProject polls the marketplace for new versions of a project. See
+// WatchProject.
+func (m *Market) WatchProject(ctx context.Context, project string) (<-chan *Release, error) {
+
+	list, err := m.ListVersions(project)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]bool)
+	for _, v := range list {
+		refs[v.Ref] = true
+	}
+
+	ch := make(chan *Release)
+
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(WatchInterval)
+		defer ticker.Stop()
+
+		send := func(r *Release) bool {
+			select {
+			case ch <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			list, err := m.ListVersions(project)
+			if err != nil {
+				if !send(&Release{Project: project, Err: err}) {
+					return
+				}
+				continue
+			}
+
+			// ListVersions is newest first.
+			for i := len(list) - 1; i >= 0; i-- {
+				v := list[i]
+				if refs[v.Ref] {
+					continue
+				}
+				refs[v.Ref] = true
+				if !send(&Release{Project: project, Version: v}) {
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
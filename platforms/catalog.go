@@ -0,0 +1,157 @@
+package platforms
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// Catalog caches the list of each organization's platforms for a fixed time,
+// and merges concurrent fetches for the same organization into a single
+// request. It is safe for concurrent use.
+type Catalog struct {
+	client *api.Client
+	ttl    time.Duration
+
+	lock     sync.Mutex
+	cache    map[string]*catalogEntry
+	inflight map[string]*catalogCall
+}
+
+type catalogEntry struct {
+	list    []*Platform
+	expires time.Time
+}
+
+type catalogCall struct {
+	done chan struct{}
+	list []*Platform
+	err  error
+}
+
+// NewCatalog returns a Catalog that caches platform lists for ttl.
+func NewCatalog(client *api.Client, ttl time.Duration) *Catalog {
+	return &Catalog{
+		client:   client,
+		ttl:      ttl,
+		cache:    make(map[string]*catalogEntry),
+		inflight: make(map[string]*catalogCall),
+	}
+}
+
+// List returns every platform accessible to the client for the named
+// organization, from the cache if it has not expired. See List.
+func (c *Catalog) List(org string) ([]*Platform, error) {
+
+	c.lock.Lock()
+
+	if e, ok := c.cache[org]; ok {
+		if time.Now().Before(e.expires) {
+			c.lock.Unlock()
+			return copyPlatforms(e.list), nil
+		}
+		delete(c.cache, org)
+	}
+
+	c.lock.Unlock()
+
+	return c.Refresh(org)
+}
+
+// Refresh fetches the named organization's platforms from VMS, replacing any
+// cached list. If a fetch for the organization is already in progress, Refresh
+// waits for it instead of starting another.
+func (c *Catalog) Refresh(org string) ([]*Platform, error) {
+
+	c.lock.Lock()
+
+	if call, ok := c.inflight[org]; ok {
+		c.lock.Unlock()
+		<-call.done
+		return copyPlatforms(call.list), call.err
+	}
+
+	call := &catalogCall{done: make(chan struct{})}
+	c.inflight[org] = call
+	c.lock.Unlock()
+
+	call.list, call.err = List(c.client, org)
+
+	c.lock.Lock()
+	delete(c.inflight, org)
+	if call.err == nil {
+		c.cache[org] = &catalogEntry{
+			list:    call.list,
+			expires: time.Now().Add(c.ttl),
+		}
+	}
+	c.lock.Unlock()
+
+	close(call.done)
+
+	return copyPlatforms(call.list), call.err
+}
+
+// Lookup returns the named platform from the organization's cached platform
+// list, or ErrPlatformNotFound if it isn't there. Like List, Lookup does not
+// include the platform's features or status; use Get for those.
+func (c *Catalog) Lookup(org, name string) (*Platform, error) {
+
+	list, err := c.List(org)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range list {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	return nil, ErrPlatformNotFound
+}
+
+// Exists checks if the named platform is accessible to the client for the
+// named organization, using the cached platform list. See Exists.
+func (c *Catalog) Exists(org, name string) (bool, error) {
+
+	_, err := c.Lookup(org, name)
+	if err == ErrPlatformNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Invalidate discards the cached platform list for the named organization, so
+// that the next lookup for it goes to VMS. Fetches already in progress are
+// unaffected.
+func (c *Catalog) Invalidate(org string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.cache, org)
+}
+
+// InvalidateAll discards every cached platform list.
+func (c *Catalog) InvalidateAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cache = make(map[string]*catalogEntry)
+}
+
+// copyPlatforms copies a cached list so that callers can't modify the cache.
+func copyPlatforms(list []*Platform) []*Platform {
+	if list == nil {
+		return nil
+	}
+	cp := make([]*Platform, len(list))
+	for i, p := range list {
+		x := *p
+		cp[i] = &x
+	}
+	return cp
+}
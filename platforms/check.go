@@ -0,0 +1,79 @@
+package platforms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// HealthStatus is the outcome of a platform health check.
+type HealthStatus string
+
+// Health check outcomes reported by VMS.
+const (
+	Healthy       HealthStatus = "healthy"
+	Unreachable   HealthStatus = "unreachable"
+	AuthFailure   HealthStatus = "auth-failure"
+	QuotaExceeded HealthStatus = "quota-exceeded"
+)
+
+// Health is the result of a platform health check. Message explains any
+// problem found, and Latency is how long VMS took to reach the platform.
+type Health struct {
+	Status  HealthStatus  `json:"status"`
+	Message string        `json:"message"`
+	Latency time.Duration `json:"latency"`
+	Checked time.Time     `json:"checked"`
+}
+
+// OK reports whether the platform passed the health check.
+func (h *Health) OK() bool {
+	return h.Status == Healthy
+}
+
+// Check asks VMS to contact the named platform and verify that it is reachable
+// and accepts VMS's credentials. A platform that fails the check is not an
+// error; Check only returns an error if the check itself could not be run.
+func Check(client *api.Client, org, name string) (*Health, error) {
+
+	req, err := http.NewRequest(http.MethodPost, client.URL("platforms/api/v3/orgs/%s/platforms/%s/check", org, name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrPlatformNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, api.NewAPIError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	h := new(Health)
+	err = json.Unmarshal(data, h)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.Checked.IsZero() {
+		h.Checked = time.Now()
+	}
+
+	return h, nil
+}
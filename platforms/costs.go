@@ -0,0 +1,61 @@
+package platforms
+
+import (
+	"encoding/json"
+
+	"github.com/sisatech/api"
+)
+
+// InstanceSize is a predefined instance shape a platform offers, such as a
+// cloud instance type, with its hourly price. RAM is in MiB.
+type InstanceSize struct {
+	Name   string  `json:"name"`
+	CPUs   int     `json:"cpus"`
+	RAM    int64   `json:"ram"`
+	Hourly float64 `json:"hourly"`
+}
+
+// PriceList is the pricing metadata VMS holds for a platform. Instances that
+// don't match one of the Sizes are charged Hourly, plus PerCPU for every CPU
+// and PerGiB for every GiB of memory. Prices are in Currency, and are all zero
+// if nobody has told VMS what the platform costs.
+type PriceList struct {
+	Platform string          `json:"platform"`
+	Currency string          `json:"currency"`
+	Hourly   float64         `json:"hourly"`
+	PerCPU   float64         `json:"per_cpu"`
+	PerGiB   float64         `json:"per_gib"`
+	Sizes    []*InstanceSize `json:"sizes"`
+}
+
+// Size returns the named instance size, or nil if the platform doesn't offer
+// it.
+func (p *PriceList) Size(name string) *InstanceSize {
+	for _, size := range p.Sizes {
+		if size.Name == name {
+			return size
+		}
+	}
+	return nil
+}
+
+// Costs returns the pricing metadata VMS holds for the named platform.
+func Costs(client *api.Client, org, name string) (*PriceList, error) {
+
+	data, err := getJSON(client, resourceURL(client, org, name, "costs"))
+	if err != nil {
+		return nil, platformError(err)
+	}
+
+	p := new(PriceList)
+	err = json.Unmarshal(data, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Platform == "" {
+		p.Platform = name
+	}
+
+	return p, nil
+}
@@ -0,0 +1,50 @@
+package platforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// Credentials are the secrets VMS uses to authenticate with a platform's
+// underlying hypervisor or cloud account. Which keys are required depends on
+// the platform's type, such as "username" and "password" for a hypervisor, or
+// "access_key_id" and "secret_access_key" for a cloud account.
+type Credentials map[string]string
+
+// UpdateCredentials replaces the credentials VMS uses for the named platform,
+// without otherwise changing the platform or the instances running on it. VMS
+// rejects credentials it cannot authenticate with.
+func UpdateCredentials(client *api.Client, org, name string, creds Credentials) error {
+
+	pl, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, client.URL("platforms/api/v3/orgs/%s/platforms/%s/credentials", org, name), bytes.NewReader(pl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrPlatformNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return api.NewAPIError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package platforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// ErrNoDefaultPlatform is returned by Default when the organization has not
+// chosen a default platform.
+var ErrNoDefaultPlatform = errors.New("no default platform")
+
+type defaultPL struct {
+	Name string `json:"name"`
+}
+
+// Default returns the name of the organization's default platform, which is
+// used for new instances that don't name a platform of their own.
+func Default(client *api.Client, org string) (string, error) {
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("platforms/api/v3/orgs/%s/default", org), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", ErrNoDefaultPlatform
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", api.NewAPIError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	pl := new(defaultPL)
+	err = json.Unmarshal(data, pl)
+	if err != nil {
+		return "", err
+	}
+
+	if pl.Name == "" {
+		return "", ErrNoDefaultPlatform
+	}
+
+	return pl.Name, nil
+}
+
+// SetDefault makes the named platform the organization's default platform.
+func SetDefault(client *api.Client, org, name string) error {
+
+	pl, err := json.Marshal(&defaultPL{Name: name})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, client.URL("platforms/api/v3/orgs/%s/default", org), bytes.NewReader(pl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrPlatformNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return api.NewAPIError(resp)
+	}
+
+	return nil
+}
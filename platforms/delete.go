@@ -0,0 +1,189 @@
+package platforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/sisatech/api"
+)
+
+// ErrPlatformInUse is returned by Delete when it refuses to delete a platform
+// because deployments still have instances on it.
+type ErrPlatformInUse struct {
+	Platform    string
+	Deployments []string
+}
+
+func (e *ErrPlatformInUse) Error() string {
+	return fmt.Sprintf("platform '%s' is in use by deployments: %s", e.Platform, strings.Join(e.Deployments, ", "))
+}
+
+type deploymentPL struct {
+	Name string `json:"name"`
+}
+
+type deploymentStatePL struct {
+	State struct {
+		Children map[string]json.RawMessage `json:"children"`
+	} `json:"state"`
+}
+
+type deploymentNodePL struct {
+	VM *struct {
+		Platform string `json:"platform"`
+	} `json:"vm"`
+	Subtree *struct {
+		Children map[string]json.RawMessage `json:"children"`
+	} `json:"subtree"`
+	Children map[string]json.RawMessage `json:"children"`
+	Platform string                     `json:"platform"`
+}
+
+// Delete deregisters the named platform from the organization. Unless force is
+// true, Delete first checks every deployment in the organization and returns
+// an *ErrPlatformInUse without deleting anything if any of them still have
+// instances on the platform. Instances are not destroyed by a forced delete,
+// but VMS can no longer manage them.
+func Delete(client *api.Client, org, name string, force bool) error {
+
+	if !force {
+		users, err := deploymentsUsing(client, org, name)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			return &ErrPlatformInUse{Platform: name, Deployments: users}
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, client.URL("platforms/api/v3/orgs/%s/platforms/%s", org, name), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrPlatformNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return api.NewAPIError(resp)
+	}
+
+	return nil
+}
+
+// deploymentsUsing returns the names of the organization's deployments that
+// have at least one instance on the named platform, in alphabetical order.
+func deploymentsUsing(client *api.Client, org, platform string) ([]string, error) {
+
+	data, err := getJSON(client, client.URL("deployments/api/v3/orgs/%s/deployments/", org))
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := make([]*deploymentPL, 0)
+	err = json.Unmarshal(data, &deployments)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]string, 0)
+
+	for _, d := range deployments {
+		data, err := getJSON(client, client.URL("deployments/api/v3/orgs/%s/deployments/%s", org, d.Name))
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			// The deployment was deleted after it was listed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pl := new(deploymentStatePL)
+		err = json.Unmarshal(data, pl)
+		if err != nil {
+			return nil, err
+		}
+
+		found, err := usesPlatform(pl.State.Children, platform)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			list = append(list, d.Name)
+		}
+	}
+
+	sort.Strings(list)
+
+	return list, nil
+}
+
+// usesPlatform reports whether any instance within the children of a
+// deployment state subtree is on the named platform.
+func usesPlatform(children map[string]json.RawMessage, platform string) (bool, error) {
+
+	for _, v := range children {
+
+		node := new(deploymentNodePL)
+		err := json.Unmarshal(v, node)
+		if err != nil {
+			return false, err
+		}
+
+		sub := node.Children
+		if node.Subtree != nil {
+			sub = node.Subtree.Children
+		}
+		if sub != nil {
+			found, err := usesPlatform(sub, platform)
+			if err != nil || found {
+				return found, err
+			}
+			continue
+		}
+
+		if node.Platform == platform || (node.VM != nil && node.VM.Platform == platform) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getJSON fetches the body of a successful GET request.
+func getJSON(client *api.Client, url string) ([]byte, error) {
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, api.NewAPIError(resp)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
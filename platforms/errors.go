@@ -0,0 +1,22 @@
+package platforms
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// ErrPlatformNotFound is returned whenever the named platform does not exist
+// or is not accessible to the client.
+var ErrPlatformNotFound = errors.New("platform not found")
+
+// platformError converts an error from a request about a platform into
+// ErrPlatformNotFound if VMS reported the platform missing.
+func platformError(err error) error {
+	var e *api.APIError
+	if errors.As(err, &e) && e.StatusCode == http.StatusNotFound {
+		return ErrPlatformNotFound
+	}
+	return err
+}
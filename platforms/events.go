@@ -0,0 +1,66 @@
+package platforms
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/sisatech/api"
+)
+
+// EventType identifies the kind of activity an Event describes.
+type EventType string
+
+// Types of platform activity recorded by VMS.
+const (
+	Provisioned     EventType = "provisioned"
+	ProvisionFailed EventType = "provision-failed"
+	Deprovisioned   EventType = "deprovisioned"
+	PlatformError   EventType = "error"
+)
+
+// Event is an entry in a platform's activity log. Deployment and Instance
+// identify the instance concerned, if any, and Message gives any error
+// details reported by the platform.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Type       EventType `json:"type"`
+	Deployment string    `json:"deployment"`
+	Instance   string    `json:"instance"`
+	Message    string    `json:"message"`
+}
+
+// Failed reports whether the event records a failure.
+func (e *Event) Failed() bool {
+	return e.Type == ProvisionFailed || e.Type == PlatformError
+}
+
+// Events returns the named platform's activity log from since onwards, oldest
+// first. A zero since returns everything VMS has kept.
+func Events(client *api.Client, org, name string, since time.Time) ([]*Event, error) {
+
+	u := resourceURL(client, org, name, "events")
+	if !since.IsZero() {
+		query := url.Values{}
+		query.Set("since", since.UTC().Format(time.RFC3339))
+		u += "?" + query.Encode()
+	}
+
+	data, err := getJSON(client, u)
+	if err != nil {
+		return nil, platformError(err)
+	}
+
+	list := make([]*Event, 0)
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Time.Before(list[j].Time)
+	})
+
+	return list, nil
+}
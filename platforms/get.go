@@ -0,0 +1,70 @@
+package platforms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// Get returns the full details of the named platform, including its features
+// and current status.
+func Get(client *api.Client, org, name string) (*Platform, error) {
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("platforms/api/v3/orgs/%s/platforms/%s", org, name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrPlatformNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, api.NewAPIError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(Platform)
+	err = json.Unmarshal(data, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// SupportsNetwork reports whether the platform can attach instances to the named
+// network.
+func (p *Platform) SupportsNetwork(network string) bool {
+	for _, n := range p.Features.Networks {
+		if n == network {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsDiskFormat reports whether the platform can boot disk images of the
+// given format.
+func (p *Platform) SupportsDiskFormat(format string) bool {
+	for _, f := range p.Features.DiskFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package platforms
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/sisatech/api"
+)
+
+// ErrNoMatchingPlatform is returned by Pick when none of the organization's
+// platforms satisfy the requirements.
+var ErrNoMatchingPlatform = errors.New("no platform satisfies requirements")
+
+// Requirements describe what a platform must provide to be chosen by Pick.
+// Empty fields are ignored. CPUs and RAM, in MiB, are the free capacity the
+// platform must have left within its limits.
+type Requirements struct {
+	Type    PlatformType
+	Region  string
+	Network string
+	GPU     bool
+	CPUs    int
+	RAM     int64
+}
+
+// needsDetails reports whether checking the requirements needs more than the
+// summary returned by List.
+func (r *Requirements) needsDetails() bool {
+	return r.Network != "" || r.GPU
+}
+
+// needsUsage reports whether checking the requirements needs the platform's
+// usage.
+func (r *Requirements) needsUsage() bool {
+	return r.CPUs > 0 || r.RAM > 0
+}
+
+// Matching returns every one of the organization's platforms that satisfies
+// the requirements, in alphabetical order. Checking some requirements needs
+// further requests for each platform, so unlike List the platforms returned
+// include their features and status when a network or GPU is required.
+func Matching(client *api.Client, org string, r *Requirements) ([]*Platform, error) {
+
+	list, err := List(client, org)
+	if err != nil {
+		return nil, err
+	}
+
+	if r == nil {
+		r = new(Requirements)
+	}
+
+	matches := make([]*Platform, 0)
+
+	for _, p := range list {
+		if r.Type != "" && p.Type != r.Type {
+			continue
+		}
+		if r.Region != "" && p.Region != r.Region {
+			continue
+		}
+
+		if r.needsDetails() {
+			p, err = Get(client, org, p.Name)
+			if err == ErrPlatformNotFound {
+				// The platform was deleted after it was listed.
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if r.Network != "" && !p.SupportsNetwork(r.Network) {
+				continue
+			}
+			if r.GPU && !p.Features.GPU {
+				continue
+			}
+		}
+
+		if r.needsUsage() {
+			u, err := Usage(client, org, p.Name)
+			if err == ErrPlatformNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !u.Fits(r.CPUs, r.RAM) {
+				continue
+			}
+		}
+
+		matches = append(matches, p)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Name < matches[j].Name
+	})
+
+	return matches, nil
+}
+
+// Pick chooses one of the organization's platforms that satisfies the
+// requirements, returning ErrNoMatchingPlatform if there are none. Among
+// several matching platforms, the first in alphabetical order is chosen.
+func Pick(client *api.Client, org string, r *Requirements) (*Platform, error) {
+
+	matches, err := Matching(client, org, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return nil, ErrNoMatchingPlatform
+	}
+
+	return matches[0], nil
+}
@@ -2,55 +2,109 @@ package platforms
 
 import (
 	"encoding/json"
-	"errors"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/sisatech/api"
 )
 
-type platformsTuple struct {
-	Name string
-	Type string
+// PlatformType identifies the kind of hypervisor or cloud a platform
+// provisions instances on.
+type PlatformType string
+
+// Platform types supported by VMS. Platforms of other types may still be
+// reported by VMS, so code switching on a PlatformType should handle unknown
+// values.
+const (
+	VCenter    PlatformType = "vcenter"
+	AWS        PlatformType = "aws"
+	GCP        PlatformType = "gcp"
+	Azure      PlatformType = "azure"
+	KVM        PlatformType = "kvm"
+	HyperV     PlatformType = "hyperv"
+	VirtualBox PlatformType = "virtualbox"
+)
+
+// Status describes whether a platform is currently able to host instances.
+type Status string
+
+// Platform statuses reported by VMS.
+const (
+	StatusAvailable   Status = "available"
+	StatusDegraded    Status = "degraded"
+	StatusUnavailable Status = "unavailable"
+)
+
+// Features lists what a platform supports. Networks are the names of the
+// networks instances can be attached to, and DiskFormats are the disk image
+// formats the platform can boot. GPU is true if instances can be given GPUs.
+type Features struct {
+	Networks    []string `json:"networks"`
+	DiskFormats []string `json:"disk_formats"`
+	GPU         bool     `json:"gpu"`
 }
 
-type platformsListResponse []platformsTuple
+// Platform describes a platform the organization can deploy to. Type is the
+// kind of hypervisor or cloud it provisions instances on, and Region and Zone,
+// if not empty, are where that infrastructure is located. Features and Status
+// are only populated by Get.
+type Platform struct {
+	Name        string       `json:"name"`
+	Type        PlatformType `json:"type"`
+	Region      string       `json:"region"`
+	Zone        string       `json:"zone"`
+	Description string       `json:"description"`
+	Features    Features     `json:"features"`
+	Status      Status       `json:"status"`
+}
 
-// Exists checks if the named platform is accessible to the client for the named
+// List returns every platform accessible to the client for the named
 // organization.
-func Exists(client *api.Client, org, platform string) (bool, error) {
+func List(client *api.Client, org string) ([]*Platform, error) {
 
 	url := client.URL("platforms/api/v3/orgs/%s/platforms/", org)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	if resp.Body != nil {
 		defer resp.Body.Close()
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return false, errors.New(resp.Status)
+		return nil, api.NewAPIError(resp)
 	}
 
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	pl := make(platformsListResponse, 0)
-	err = json.Unmarshal(data, &pl)
+	list := make([]*Platform, 0)
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Exists checks if the named platform is accessible to the client for the named
+// organization.
+func Exists(client *api.Client, org, platform string) (bool, error) {
+
+	list, err := List(client, org)
 	if err != nil {
 		return false, err
 	}
 
-	for _, tuple := range pl {
-		if tuple.Name == platform {
+	for _, p := range list {
+		if p.Name == platform {
 			return true, nil
 		}
 	}
@@ -58,3 +112,26 @@ func Exists(client *api.Client, org, platform string) (bool, error) {
 	return false, nil
 
 }
+
+// ExistsAll checks which of the named platforms are accessible to the client
+// for the named organization, fetching the platform list only once. The
+// result maps every name to whether it exists.
+func ExistsAll(client *api.Client, org string, names []string) (map[string]bool, error) {
+
+	list, err := List(client, org)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	for _, p := range list {
+		known[p.Name] = true
+	}
+
+	results := make(map[string]bool)
+	for _, name := range names {
+		results[name] = known[name]
+	}
+
+	return results, nil
+}
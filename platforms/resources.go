@@ -0,0 +1,141 @@
+package platforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// Network is a network on a platform that instances can be attached to.
+// External is true if the network is reachable from outside the platform.
+type Network struct {
+	Name     string `json:"name"`
+	CIDR     string `json:"cidr"`
+	Gateway  string `json:"gateway"`
+	External bool   `json:"external"`
+}
+
+// Bucket is somewhere a platform stores instance disks, such as a vCenter
+// datastore or a cloud storage bucket. Capacity and Free are in bytes, and are
+// zero if the platform does not report them.
+type Bucket struct {
+	Name     string `json:"name"`
+	Capacity int64  `json:"capacity"`
+	Free     int64  `json:"free"`
+}
+
+// PlacementSettings are the defaults a platform uses for instances that don't
+// name a network or bucket in their customization.
+type PlacementSettings struct {
+	Network string `json:"network"`
+	Bucket  string `json:"bucket"`
+}
+
+func resourceURL(client *api.Client, org, name, resource string) string {
+	return client.URL("platforms/api/v3/orgs/%s/platforms/%s/%s", org, name, resource)
+}
+
+// ListNetworks returns the networks available on the named platform.
+func ListNetworks(client *api.Client, org, name string) ([]*Network, error) {
+
+	data, err := getJSON(client, resourceURL(client, org, name, "networks"))
+	if err != nil {
+		return nil, platformError(err)
+	}
+
+	list := make([]*Network, 0)
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ConfigureNetwork creates or updates a network on the named platform. The
+// network is identified by its name.
+func ConfigureNetwork(client *api.Client, org, name string, network *Network) error {
+	return putJSON(client, resourceURL(client, org, name, "networks/"+network.Name), network)
+}
+
+// ListBuckets returns the storage locations available on the named platform.
+func ListBuckets(client *api.Client, org, name string) ([]*Bucket, error) {
+
+	data, err := getJSON(client, resourceURL(client, org, name, "buckets"))
+	if err != nil {
+		return nil, platformError(err)
+	}
+
+	list := make([]*Bucket, 0)
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ConfigureBucket creates or updates a storage location on the named platform.
+// The bucket is identified by its name; its Capacity and Free fields are
+// ignored.
+func ConfigureBucket(client *api.Client, org, name string, bucket *Bucket) error {
+	return putJSON(client, resourceURL(client, org, name, "buckets/"+bucket.Name), bucket)
+}
+
+// GetPlacement returns the named platform's default placement settings.
+func GetPlacement(client *api.Client, org, name string) (*PlacementSettings, error) {
+
+	data, err := getJSON(client, resourceURL(client, org, name, "placement"))
+	if err != nil {
+		return nil, platformError(err)
+	}
+
+	settings := new(PlacementSettings)
+	err = json.Unmarshal(data, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// SetPlacement replaces the named platform's default placement settings. The
+// network and bucket must already exist on the platform.
+func SetPlacement(client *api.Client, org, name string, settings *PlacementSettings) error {
+	return putJSON(client, resourceURL(client, org, name, "placement"), settings)
+}
+
+// putJSON sends v as the body of a PUT request, expecting it to succeed.
+func putJSON(client *api.Client, url string, v interface{}) error {
+
+	pl, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(pl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrPlatformNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return api.NewAPIError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package platforms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/sisatech/api"
+)
+
+// Resources is an amount of each resource a platform provides. RAM is in
+// MiB.
+type Resources struct {
+	Instances int   `json:"instances"`
+	CPUs      int   `json:"cpus"`
+	RAM       int64 `json:"ram"`
+}
+
+// UsageReport describes how much of a platform's capacity the organization is
+// using. A zero field in Limits means that resource is unlimited.
+type UsageReport struct {
+	Platform string    `json:"platform"`
+	Used     Resources `json:"used"`
+	Limits   Resources `json:"limits"`
+}
+
+// Free returns how much of each resource remains before the platform's limits
+// are reached. Resources without a limit are reported as -1.
+func (u *UsageReport) Free() Resources {
+
+	free := func(used, limit int64) int64 {
+		if limit == 0 {
+			return -1
+		}
+		if used >= limit {
+			return 0
+		}
+		return limit - used
+	}
+
+	return Resources{
+		Instances: int(free(int64(u.Used.Instances), int64(u.Limits.Instances))),
+		CPUs:      int(free(int64(u.Used.CPUs), int64(u.Limits.CPUs))),
+		RAM:       free(u.Used.RAM, u.Limits.RAM),
+	}
+}
+
+// Fits reports whether the platform has room for an instance that needs the
+// given number of CPUs and MiB of RAM. Zero requirements are ignored.
+func (u *UsageReport) Fits(cpus int, ram int64) bool {
+	free := u.Free()
+	if free.Instances == 0 {
+		return false
+	}
+	if cpus > 0 && free.CPUs >= 0 && free.CPUs < cpus {
+		return false
+	}
+	if ram > 0 && free.RAM >= 0 && free.RAM < ram {
+		return false
+	}
+	return true
+}
+
+// Usage returns the organization's current consumption and configured limits
+// on the named platform.
+func Usage(client *api.Client, org, name string) (*UsageReport, error) {
+
+	req, err := http.NewRequest(http.MethodGet, client.URL("platforms/api/v3/orgs/%s/platforms/%s/usage", org, name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrPlatformNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, api.NewAPIError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	u := new(UsageReport)
+	err = json.Unmarshal(data, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Platform == "" {
+		u.Platform = name
+	}
+
+	return u, nil
+}